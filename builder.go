@@ -0,0 +1,83 @@
+package errors
+
+// Builder accumulates the properties of an Error. Unlike the methods of Error,
+// its methods can be made conditional without breaking the chain of calls:
+//
+//	e := errors.New("query failed").Build().
+//		Code(500).
+//		When(user != "").AddInfo("user", user).
+//		AddInfo("table", table).
+//		Done()
+type Builder struct {
+	err  *Error
+	skip bool
+}
+
+// Build returns a Builder decorating the error.
+func (e *Error) Build() Builder {
+	return Builder{err: e}
+}
+
+// When makes the next call of the chain conditional: it is skipped if cond is
+// false. The calls that follow are not affected.
+func (b Builder) When(cond bool) Builder {
+	return Builder{err: b.err, skip: b.skip || !cond}
+}
+
+// apply calls fn unless the call is skipped, and returns an unconditional
+// Builder.
+func (b Builder) apply(fn func(*Error)) Builder {
+	if !b.skip {
+		fn(b.err)
+	}
+	return Builder{err: b.err}
+}
+
+// AddInfo records information as Error.AddInfo does.
+func (b Builder) AddInfo(key string, value interface{}) Builder {
+	return b.apply(func(e *Error) { e.AddInfo(key, value) })
+}
+
+// AddInfos records several information pairs as Error.AddInfos does.
+func (b Builder) AddInfos(m map[string]interface{}) Builder {
+	return b.apply(func(e *Error) { e.AddInfos(m) })
+}
+
+// Code sets the error code as Error.Code does.
+func (b Builder) Code(c int) Builder {
+	return b.apply(func(e *Error) { e.Code(c) })
+}
+
+// CodeString sets a symbolic error code as Error.CodeString does.
+func (b Builder) CodeString(c string) Builder {
+	return b.apply(func(e *Error) { e.CodeString(c) })
+}
+
+// Severity sets the severity level as Error.Severity does.
+func (b Builder) Severity(level Severity) Builder {
+	return b.apply(func(e *Error) { e.Severity(level) })
+}
+
+// HTTPStatus sets the HTTP status code as Error.HTTPStatus does.
+func (b Builder) HTTPStatus(code int) Builder {
+	return b.apply(func(e *Error) { e.HTTPStatus(code) })
+}
+
+// Detail sets the developer-only detail as Error.Detail does.
+func (b Builder) Detail(detail string) Builder {
+	return b.apply(func(e *Error) { e.Detail(detail) })
+}
+
+// Wraps makes the error wrap E. As with Error.Wraps, the error being built is
+// replaced by a copy, so that the original one is left untouched.
+func (b Builder) Wraps(E error) Builder {
+	if !b.skip {
+		b.err = b.err.Wraps(E)
+	}
+	return Builder{err: b.err}
+}
+
+// Done returns the error that was built.
+func (b Builder) Done() *Error {
+	return b.err
+}
@@ -0,0 +1,41 @@
+package errors_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestBuilder(t *testing.T) {
+	build := func(user string, retry bool) *errors.Error {
+		return errors.Constructor(errors.JSONCodec)("query failed").Build().
+			Code(500).
+			When(user != "").AddInfo("user", user).
+			When(retry).Severity(errors.SeverityWarn).
+			AddInfo("table", "users").
+			When(retry).When(user != "").Wraps(io.EOF).
+			Done()
+	}
+
+	e := build("alice", true)
+	if !e.HasCode(500) || e.ErrorInfo["user"] != "alice" || e.ErrorInfo["table"] != "users" || e.GetSeverity() != errors.SeverityWarn {
+		t.Fatalf("unexpected error: %#v", e)
+	}
+	if e.Source() != io.EOF {
+		t.Fatal("expected the error to be wrapped")
+	}
+
+	e = build("", true)
+	if e.HasInfo("user") || e.ErrorInfo["table"] != "users" || e.GetSeverity() != errors.SeverityWarn {
+		t.Fatalf("expected only the conditional call to be skipped, got %#v", e.ErrorInfo)
+	}
+	if e.Source() != nil {
+		t.Fatal("expected the nested conditions to be combined")
+	}
+
+	e = build("bob", false)
+	if e.ErrorInfo["user"] != "bob" || e.GetSeverity() != errors.SeverityError || e.Source() != nil {
+		t.Fatalf("unexpected error: %#v", e)
+	}
+}
@@ -0,0 +1,94 @@
+package errors
+
+import (
+	"sync"
+)
+
+// catalog holds the errors declared with Register and the translations of
+// their messages declared with RegisterMessage, by code.
+var catalog = struct {
+	sync.RWMutex
+	entries     map[int]catalogEntry
+	messages    map[int]map[string]string
+	defaultLang string
+}{defaultLang: "en"}
+
+type catalogEntry struct {
+	message string
+	create  func(string) *Error
+}
+
+// Register declares the error identified by code, so that it is defined once
+// and retrieved with Lookup wherever it is returned. The options are applied
+// as by a Constructor using the JSONCodec; the code takes precedence over the
+// one set by WithDefaultCode, if any.
+// Registering a code again replaces the previous declaration.
+func Register(code int, message string, options ...Option) {
+	options = append(options[:len(options):len(options)], WithDefaultCode(code))
+	catalog.Lock()
+	defer catalog.Unlock()
+	if catalog.entries == nil {
+		catalog.entries = make(map[int]catalogEntry)
+	}
+	catalog.entries[code] = catalogEntry{message, Constructor(JSONCodec, options...)}
+}
+
+// Lookup returns a new Error as declared by Register for code, or nil if the
+// code was not registered. Each call returns a distinct Error, so that callers
+// can decorate it without affecting the others. The information functions, if
+// any, are called anew.
+func Lookup(code int) *Error {
+	catalog.RLock()
+	entry, ok := catalog.entries[code]
+	catalog.RUnlock()
+	if !ok {
+		return nil
+	}
+	return entry.create(entry.message)
+}
+
+// RegisterMessage declares the message of the errors with the given code in
+// the language lang, e.g. "fr". The messages are used by Localize.
+func RegisterMessage(code int, lang string, msg string) {
+	catalog.Lock()
+	defer catalog.Unlock()
+	if catalog.messages == nil {
+		catalog.messages = make(map[int]map[string]string)
+	}
+	if catalog.messages[code] == nil {
+		catalog.messages[code] = make(map[string]string)
+	}
+	catalog.messages[code][lang] = msg
+}
+
+// SetDefaultLanguage sets the language whose messages are used by Localize
+// when none is registered for the requested language. It is "en" by default.
+func SetDefaultLanguage(lang string) {
+	catalog.Lock()
+	catalog.defaultLang = lang
+	catalog.Unlock()
+}
+
+// Localize returns a copy of the error in which the cause of every Error of the
+// chain, and of the merged causes, is replaced by the message registered for its code in the language
+// lang or, failing that, in the default language. Errors without a registered
+// message keep their cause.
+// It allows to serialize user-facing errors in the language of the request.
+func (e *Error) Localize(lang string) *Error {
+	c := e.Clone()
+	catalog.RLock()
+	defer catalog.RUnlock()
+	c.walkTree(func(err *Error) {
+		code, ok := err.GetCode()
+		if !ok {
+			return
+		}
+		msgs := catalog.messages[code]
+		if msg, ok := msgs[lang]; ok {
+			err.ErrorCause = msg
+		} else if msg, ok := msgs[catalog.defaultLang]; ok {
+			err.ErrorCause = msg
+		}
+	})
+	return c
+}
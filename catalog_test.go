@@ -0,0 +1,55 @@
+package errors_test
+
+import (
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestCatalog(t *testing.T) {
+	errors.Register(40401, "user not found", errors.WithSeverity(errors.SeverityWarn))
+	errors.Register(50001, "database unavailable", errors.WithDefaultCode(500), errors.WithInfoFuncs(errors.PrintLine))
+
+	e := errors.Lookup(40401)
+	if e == nil || e.ErrorCause != "user not found" || !e.HasCode(40401) || e.ErrorSeverity != errors.SeverityWarn {
+		t.Fatalf("unexpected error: %#v", e)
+	}
+	if e := errors.Lookup(50001); !e.HasCode(50001) || !e.HasInfo("line") {
+		t.Fatalf("expected the registered code and information, got %#v", e)
+	}
+	if errors.Lookup(404) != nil {
+		t.Fatal("expected nil for an unregistered code")
+	}
+
+	e.AddInfo("user", "alice")
+	e.ErrorCause = "changed"
+	other := errors.Lookup(40401)
+	if other == e || other.HasInfo("user") || other.ErrorCause != "user not found" {
+		t.Fatalf("expected lookups to return independent errors, got %#v", other)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	errors.Register(40402, "order not found")
+	errors.RegisterMessage(40402, "en", "The order could not be found.")
+	errors.RegisterMessage(40402, "fr", "La commande est introuvable.")
+
+	e := errors.Lookup(40402)
+	if got := e.Localize("en").ErrorCause; got != "The order could not be found." {
+		t.Fatalf("unexpected English message: %q", got)
+	}
+	if got := e.Localize("fr").ErrorCause; got != "La commande est introuvable." {
+		t.Fatalf("unexpected French message: %q", got)
+	}
+	if got := e.Localize("de").ErrorCause; got != "The order could not be found." {
+		t.Fatalf("expected a fallback to the default language, got %q", got)
+	}
+	if e.ErrorCause != "order not found" {
+		t.Fatal("expected the original error to be left untouched")
+	}
+
+	w := errors.New("checkout failed").Wraps(e).Localize("fr")
+	if w.ErrorCause != "checkout failed" || w.Underlying.ErrorCause != "La commande est introuvable." {
+		t.Fatalf("expected the chain to be localized, got %v", w.FullMessage())
+	}
+}
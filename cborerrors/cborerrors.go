@@ -0,0 +1,116 @@
+// Package cborerrors provides a codec serializing the Error type of the errors
+// package as CBOR, a compact binary format suited to constrained devices and
+// low-bandwidth links.
+// It lives in its own package so that the errors package does not depend on a
+// CBOR library.
+package cborerrors
+
+import (
+	"reflect"
+
+	"github.com/atdiar/errors"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBORCodec is an Error Encoder/Decoder object using CBOR as the serialization
+// format. Unlike the GobCodec, it does not require the types of the
+// information values to be registered: they are decoded as the nearest Go
+// types, i.e. integers as int64, maps as map[string]interface{} and arrays as
+// []interface{}. The encoding is deterministic.
+// A wrapped error that is not of type Error is encoded as an Error holding its
+// message. The causes merged with Merge or wrapped by WrapsAll are encoded
+// under the ErrorCauses field.
+var CBORCodec errors.Codec
+
+var (
+	encMode cbor.EncMode
+	decMode cbor.DecMode
+)
+
+func init() {
+	var err error
+	encMode, err = cbor.EncOptions{Sort: cbor.SortCanonical}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	decMode, err = cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+		IntDec:         cbor.IntDecConvertSigned,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	CBORCodec = errors.NewCodec(toCBOR, fromCBOR)
+}
+
+type cborError struct {
+	Info     map[string]interface{} `cbor:"ErrorInfo,omitempty"`
+	Code     string                 `cbor:"ErrorCode,omitempty"`
+	Cause    string                 `cbor:"ErrorCause"`
+	Severity int                    `cbor:"ErrorSeverity,omitempty"`
+	Stack    []errors.Frame         `cbor:"ErrorStack,omitempty"`
+	Source   *cborError             `cbor:"ErrorSource,omitempty"`
+	Causes   []*cborError           `cbor:"ErrorCauses,omitempty"`
+}
+
+func newCBORError(e *errors.Error) *cborError {
+	if e == nil {
+		return nil
+	}
+	c := &cborError{
+		Info:     e.ErrorInfo,
+		Code:     e.ErrorCode,
+		Cause:    e.ErrorCause,
+		Severity: int(e.ErrorSeverity),
+		Stack:    e.ErrorStack,
+		Source:   newCBORError(e.Underlying),
+	}
+	if e.Underlying == nil {
+		if std := e.Source(); std != nil {
+			c.Source = &cborError{Cause: std.Error()}
+		}
+	}
+	for _, b := range e.Branches() {
+		if be := errors.As(b); be != nil {
+			c.Causes = append(c.Causes, newCBORError(be))
+			continue
+		}
+		c.Causes = append(c.Causes, &cborError{Cause: b.Error()})
+	}
+	return c
+}
+
+func (c *cborError) toError() *errors.Error {
+	if c == nil {
+		return nil
+	}
+	e := errors.Decoded(CBORCodec, c.Cause).CodeString(c.Code).Severity(errors.Severity(c.Severity))
+	e.ErrorStack = c.Stack
+	e.Underlying = c.Source.toError()
+	for k, v := range c.Info {
+		e.AddInfo(k, v)
+	}
+	for _, m := range c.Causes {
+		e.ErrorCauses = append(e.ErrorCauses, m.toError())
+	}
+	return e
+}
+
+// toCBOR encodes an Error as CBOR. The chain is cloned beforehand, so that a
+// cyclic chain is encoded up to the first repeated Error.
+func toCBOR(i interface{}) ([]byte, error) {
+	if e, ok := i.(*errors.Error); ok && e != nil {
+		i = newCBORError(e.Clone())
+	}
+	return encMode.Marshal(i)
+}
+
+// fromCBOR decodes CBOR data into an Error. If the data is malformed, the
+// returned Error holds the failure as its cause.
+func fromCBOR(b []byte) *errors.Error {
+	var c cborError
+	if err := decMode.Unmarshal(b, &c); err != nil {
+		return errors.Decoded(CBORCodec, "cborerrors: unable to decode: "+err.Error())
+	}
+	return c.toError()
+}
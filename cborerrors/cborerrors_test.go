@@ -0,0 +1,68 @@
+package cborerrors_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+	"github.com/atdiar/errors/cborerrors"
+)
+
+func TestRoundTrip(t *testing.T) {
+	newError := errors.Constructor(cborerrors.CBORCodec)
+	e := newError("query failed").Code(500).Severity(errors.SeverityWarn).
+		AddInfo("rows", 12).AddInfo("offset", -3).AddInfo("ratio", 0.5).AddInfo("retry", true).
+		AddInfo("host", "db1").AddInfo("tags", []string{"a", "b"}).
+		AddInfo("params", map[string]interface{}{"id": 42}).
+		Wraps(newError("timeout").CodeString("E_TIMEOUT"))
+
+	b, err := cborerrors.CBORCodec.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j, _ := errors.CompactJSONCodec.Encode(e); len(b) >= len(j) {
+		t.Fatalf("expected the CBOR encoding to be more compact than JSON, got %d bytes vs %d", len(b), len(j))
+	}
+
+	d := cborerrors.CBORCodec.Decode(b)
+	if d.ErrorCause != "query failed" || !d.HasCode(500) || d.GetSeverity() != errors.SeverityWarn {
+		t.Fatalf("unexpected error: %#v", d)
+	}
+	want := map[string]interface{}{
+		"rows":   int64(12),
+		"offset": int64(-3),
+		"ratio":  0.5,
+		"retry":  true,
+		"host":   "db1",
+		"tags":   []interface{}{"a", "b"},
+		"params": map[string]interface{}{"id": int64(42)},
+	}
+	if !reflect.DeepEqual(d.ErrorInfo, want) {
+		t.Fatalf("expected %#v, got %#v", want, d.ErrorInfo)
+	}
+	if d.Underlying == nil || d.Underlying.ErrorCause != "timeout" || !d.Underlying.IsCode("E_TIMEOUT") {
+		t.Fatalf("unexpected underlying error: %#v", d.Underlying)
+	}
+
+	again, err := cborerrors.CBORCodec.Encode(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(b) {
+		t.Fatal("expected the encoding to be deterministic")
+	}
+
+	m := e.Merge(newError("fallback failed").Code(504))
+	b, err = cborerrors.CBORCodec.Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cborerrors.CBORCodec.Decode(b); !errors.Equal(d, m) {
+		t.Fatalf("expected the merged causes to survive the round trip, got %v", d)
+	}
+
+	if d := cborerrors.CBORCodec.Decode([]byte{0xff}); !strings.HasPrefix(d.ErrorCause, "cborerrors: unable to decode") {
+		t.Fatalf("expected malformed data to be reported, got %q", d.ErrorCause)
+	}
+}
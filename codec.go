@@ -0,0 +1,329 @@
+package errors
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// codecs holds the codecs registered by content type.
+var codecs = struct {
+	sync.RWMutex
+	byType map[string]Codec
+}{
+	byType: map[string]Codec{"application/json": JSONCodec},
+}
+
+// RegisterCodec records the codec used to decode errors received with the
+// given content type, e.g. "application/xml". A codec registered for a content
+// type replaces the previous one.
+// The "application/json" content type is mapped to the JSONCodec by default.
+func RegisterCodec(contentType string, c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.byType[mediaType(contentType)] = c
+}
+
+// CodecFor returns the codec registered for the given content type. Parameters
+// of the content type, such as the charset, are ignored.
+func CodecFor(contentType string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.byType[mediaType(contentType)]
+	return c, ok
+}
+
+// DecodeFrom decodes data with the codec registered for the given content
+// type. If no codec is registered, the returned Error holds the failure as its
+// cause.
+func DecodeFrom(contentType string, data []byte) *Error {
+	c, ok := CodecFor(contentType)
+	if !ok {
+		return &Error{ErrorCause: "errors: no codec registered for content type " + contentType, codec: JSONCodec}
+	}
+	return c.Decode(data)
+}
+
+// mediaType returns the normalized media type of a content type.
+func mediaType(contentType string) string {
+	if t, _, err := mime.ParseMediaType(contentType); err == nil {
+		return t
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// Limits bounds the size of the errors encoded by a Limited codec. A zero
+// value means no limit.
+type Limits struct {
+	// MaxDepth is the maximum number of Errors of a chain that are encoded.
+	MaxDepth int
+	// MaxInfoBytes is the maximum size of the information encoded for a whole
+	// chain, counting the keys and the JSON form of the values.
+	MaxInfoBytes int
+}
+
+// Limited returns a codec that encodes errors with c after enforcing limits,
+// so that an error received from an untrusted party cannot make the encoding
+// balloon. Each branch of the chain, including the causes merged with Merge or
+// wrapped by WrapsAll, is cut after limits.MaxDepth errors and the number of
+// errors left out is recorded on the last one kept under the "chain_truncated"
+// key.
+// Information entries are kept, from the outermost error to the innermost and
+// by key order, as long as they fit in limits.MaxInfoBytes; the number of
+// entries dropped from an error is recorded under its "info_truncated" key.
+// Decoding is left to c.
+func Limited(c Codec, limits Limits) Codec {
+	return NewCodec(func(i interface{}) ([]byte, error) {
+		e, ok := i.(*Error)
+		if !ok || e == nil {
+			return c.Encode(i)
+		}
+		return c.Encode(limits.apply(e))
+	}, c.Decode)
+}
+
+// apply returns a copy of e within the limits.
+func (limits Limits) apply(e *Error) *Error {
+	l := e.Clone()
+	budget := limits.MaxInfoBytes
+	visited := make(map[*Error]bool)
+	var limit func(err *Error, depth int)
+	limit = func(err *Error, depth int) {
+		for ; err != nil && !visited[err]; err = err.Underlying {
+			visited[err] = true
+			depth++
+			if limits.MaxInfoBytes > 0 {
+				budget = err.truncateInfo(budget)
+			}
+			if limits.MaxDepth > 0 && depth == limits.MaxDepth {
+				if dropped := err.cut(); dropped > 0 {
+					err.AddInfo("chain_truncated", dropped)
+				}
+				return
+			}
+			for _, m := range err.ErrorCauses {
+				limit(m, depth)
+			}
+		}
+	}
+	limit(l, 0)
+	return l
+}
+
+// cut removes the errors wrapped by e, along with their own chains and
+// branches, and returns their number.
+func (e *Error) cut() int {
+	n := len(e.causesStd)
+	if e.Underlying == nil && e.underlyingStd != nil {
+		n++
+	}
+	for _, c := range append([]*Error{e.Underlying}, e.ErrorCauses...) {
+		c.walkTree(func(*Error) { n++ })
+	}
+	e.Underlying = nil
+	e.underlyingStd = nil
+	e.ErrorCauses = nil
+	e.causesStd = nil
+	return n
+}
+
+// truncateInfo drops the information entries that do not fit in budget bytes
+// and returns the remaining budget.
+func (e *Error) truncateInfo(budget int) int {
+	keys := make([]string, 0, len(e.ErrorInfo))
+	for k := range e.ErrorInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	dropped := 0
+	for _, k := range keys {
+		size := len(k)
+		if b, err := json.Marshal(e.ErrorInfo[k]); err == nil {
+			size += len(b)
+		} else {
+			size += len(fmt.Sprint(e.ErrorInfo[k]))
+		}
+		if size > budget {
+			delete(e.ErrorInfo, k)
+			dropped++
+			continue
+		}
+		budget -= size
+	}
+	if dropped > 0 {
+		e.ErrorInfo["info_truncated"] = dropped
+	}
+	return budget
+}
+
+// B64 returns a codec that encodes errors with c and then as unpadded base64url
+// text, which is ASCII and holds on a single line, so that an error can be
+// sent in an HTTP header. Decoding reverses the base64url encoding and is then
+// left to c. If the data is not valid base64url, the returned Error holds the
+// failure as its cause.
+func B64(c Codec) Codec {
+	enc := base64.RawURLEncoding
+	return NewCodec(func(i interface{}) ([]byte, error) {
+		b, err := c.Encode(i)
+		if err != nil {
+			return nil, err
+		}
+		dst := make([]byte, enc.EncodedLen(len(b)))
+		enc.Encode(dst, b)
+		return dst, nil
+	}, func(b []byte) *Error {
+		dst := make([]byte, enc.DecodedLen(len(b)))
+		n, err := enc.Decode(dst, bytes.TrimSpace(b))
+		if err != nil {
+			return &Error{ErrorCause: "errors: unable to decode base64: " + err.Error(), codec: c}
+		}
+		return c.Decode(dst[:n])
+	})
+}
+
+// Markers of the first byte of the payloads encoded by a Gzip codec.
+const (
+	gzipRaw        byte = 0
+	gzipCompressed byte = 1
+)
+
+// maxDecompressedSize is the size above which a payload decompressed by a Gzip
+// codec is rejected, so that a small payload cannot exhaust the memory.
+const maxDecompressedSize = 64 << 20
+
+// Gzip returns a codec that encodes errors with c and compresses the result
+// with gzip if it is at least threshold bytes long, so that small payloads do
+// not pay for the compression overhead. The first byte of the payload tells
+// whether the rest is compressed. Decoding reverses the compression, if any,
+// and is then left to c. If the payload is malformed, or decompresses to more
+// than 64 MiB, the returned Error holds the failure as its cause.
+func Gzip(c Codec, threshold int) Codec {
+	return NewCodec(func(i interface{}) ([]byte, error) {
+		b, err := c.Encode(i)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < threshold {
+			return append([]byte{gzipRaw}, b...), nil
+		}
+		var buf bytes.Buffer
+		buf.WriteByte(gzipCompressed)
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(b); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}, func(b []byte) *Error {
+		if len(b) == 0 {
+			return &Error{ErrorCause: "errors: unable to decode: empty payload", codec: c}
+		}
+		switch b[0] {
+		case gzipRaw:
+			return c.Decode(b[1:])
+		case gzipCompressed:
+			zr, err := gzip.NewReader(bytes.NewReader(b[1:]))
+			if err != nil {
+				return &Error{ErrorCause: "errors: unable to decompress: " + err.Error(), codec: c}
+			}
+			data, err := io.ReadAll(io.LimitReader(zr, maxDecompressedSize+1))
+			if err != nil {
+				return &Error{ErrorCause: "errors: unable to decompress: " + err.Error(), codec: c}
+			}
+			if len(data) > maxDecompressedSize {
+				return &Error{ErrorCause: fmt.Sprintf("errors: unable to decompress: payload larger than %d bytes", maxDecompressedSize), codec: c}
+			}
+			return c.Decode(data)
+		}
+		return &Error{ErrorCause: fmt.Sprintf("errors: unable to decode: unknown marker %#x", b[0]), codec: c}
+	})
+}
+
+// DecodeStream decodes the errors read from r, one per line, with codec, and
+// sends them on the returned channel, which is closed once r is exhausted.
+// It suits logs written with the CompactJSONCodec. Empty lines are skipped.
+// A malformed line does not stop the decoding: the JSON codecs return an Error
+// whose cause is the raw line. If r cannot be read, an Error holding the
+// failure as its cause is sent last.
+// The channel should be drained, or the decoding goroutine is leaked.
+func DecodeStream(r io.Reader, codec Codec) <-chan *Error {
+	ch := make(chan *Error)
+	go func() {
+		defer close(ch)
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for s.Scan() {
+			line := s.Bytes()
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			e := codec.Decode(append([]byte(nil), line...))
+			if e == nil {
+				e = &Error{ErrorCause: string(line), codec: codec}
+			}
+			ch <- e
+		}
+		if err := s.Err(); err != nil {
+			ch <- &Error{ErrorCause: "errors: unable to read stream: " + err.Error(), codec: codec}
+		}
+	}()
+	return ch
+}
+
+// maxFrameSize is the size above which a frame read by ReadError is rejected.
+const maxFrameSize = 64 << 20
+
+// WriteTo writes the error to w as a frame made of the length of its encoded
+// form, as a 4-byte big-endian integer, followed by the encoded form itself.
+// The error is encoded with its codec, after redaction. It can be read back
+// with ReadError.
+// It returns the number of bytes written.
+func (e *Error) WriteTo(w io.Writer) (int64, error) {
+	c := e.Clone()
+	c.applyRedactor()
+	payload, err := e.codec.Encode(c)
+	if err != nil {
+		return 0, err
+	}
+	if len(payload) > maxFrameSize {
+		return 0, fmt.Errorf("errors: frame of %d bytes exceeds the maximum size", len(payload))
+	}
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	n, err := w.Write(frame)
+	return int64(n), err
+}
+
+// ReadError reads a frame written by WriteTo from r and decodes it with codec.
+// It returns io.EOF if r is exhausted before the frame starts, and
+// io.ErrUnexpectedEOF if the frame is truncated.
+func ReadError(r io.Reader, codec Codec) (*Error, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("errors: frame of %d bytes exceeds the maximum size", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return codec.Decode(payload), nil
+}
@@ -0,0 +1,279 @@
+package errors_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestCodecDeterministic(t *testing.T) {
+	codecs := map[string]errors.Codec{
+		"json":         errors.JSONCodec,
+		"compact json": errors.CompactJSONCodec,
+		"xml":          errors.XMLCodec,
+		"gob":          errors.GobCodec,
+	}
+	for name, codec := range codecs {
+		newError := errors.Constructor(codec)
+		a := newError("failed").Code(500).AddInfo("a", 1).AddInfo("b", "two").AddInfo("c", true).AddInfo("d", 4.5)
+		b := newError("failed").Code(500).AddInfo("d", 4.5).AddInfo("c", true).AddInfo("b", "two").AddInfo("a", 1)
+
+		for i := 0; i < 10; i++ {
+			ea, err := codec.Encode(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			eb, err := codec.Encode(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(ea, eb) {
+				t.Fatalf("%s: expected identical encodings, got\n%s\nand\n%s", name, ea, eb)
+			}
+		}
+	}
+}
+
+func TestCodecRegistry(t *testing.T) {
+	if c, ok := errors.CodecFor("application/json; charset=utf-8"); !ok || c.Encode == nil {
+		t.Fatal("expected the JSON codec to be registered by default")
+	}
+	if _, ok := errors.CodecFor("application/x-unknown"); ok {
+		t.Fatal("expected no codec to be registered for an unknown content type")
+	}
+
+	errors.RegisterCodec("application/xml", errors.XMLCodec)
+	payload, err := errors.XMLCodec.Encode(errors.New("not found").Code(404))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := errors.DecodeFrom("Application/XML", payload)
+	if e.ErrorCause != "not found" || !e.HasCode(404) {
+		t.Fatalf("expected the payload to be decoded by the XML codec, got %#v", e)
+	}
+
+	payload, err = errors.JSONCodec.Encode(errors.New("timeout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := errors.DecodeFrom("application/json", payload); e.ErrorCause != "timeout" {
+		t.Fatalf("expected the payload to be decoded by the JSON codec, got %#v", e)
+	}
+	if e := errors.DecodeFrom("text/plain", payload); !strings.Contains(e.ErrorCause, "no codec") {
+		t.Fatalf("expected the missing codec to be reported, got %s", e.ErrorCause)
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	var buf bytes.Buffer
+	for _, msg := range []string{"first", "second"} {
+		b, err := errors.CompactJSONCodec.Encode(errors.New(msg).Code(500))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(b)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("not json at all\n\n")
+	b, _ := errors.CompactJSONCodec.Encode(errors.New("third"))
+	buf.Write(b)
+
+	var causes []string
+	for e := range errors.DecodeStream(&buf, errors.CompactJSONCodec) {
+		causes = append(causes, e.ErrorCause)
+		if e.ErrorCause == "not json at all" && !strings.Contains(e.Error(), "not json at all") {
+			t.Fatalf("expected the malformed line to be printable, got %s", e.Error())
+		}
+	}
+	want := []string{"first", "second", "not json at all", "third"}
+	if strings.Join(causes, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %v, got %v", want, causes)
+	}
+}
+
+func TestFraming(t *testing.T) {
+	var buf bytes.Buffer
+	sent := []*errors.Error{
+		errors.Constructor(errors.GobCodec)("first").Code(500).AddInfo("attempt", 1),
+		errors.Constructor(errors.GobCodec)("second").Wraps(errors.New("root")),
+	}
+	for _, e := range sent {
+		n, err := e.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n <= 4 {
+			t.Fatalf("unexpected frame size: %d", n)
+		}
+	}
+
+	for _, want := range sent {
+		got, err := errors.ReadError(&buf, errors.GobCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !errors.Equal(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if _, err := errors.ReadError(&buf, errors.GobCodec); err != io.EOF {
+		t.Fatalf("expected io.EOF at the end of the stream, got %v", err)
+	}
+
+	if _, err := errors.ReadError(bytes.NewReader([]byte{0, 0, 0, 10, 'x'}), errors.GobCodec); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected a truncated frame to be reported, got %v", err)
+	}
+}
+
+func TestLimited(t *testing.T) {
+	codec := errors.Limited(errors.JSONCodec, errors.Limits{MaxDepth: 3, MaxInfoBytes: 64})
+
+	var e *errors.Error
+	for i := 0; i < 10; i++ {
+		next := errors.Constructor(codec)("level " + strconv.Itoa(i))
+		if e != nil {
+			next = next.Wraps(e)
+		}
+		e = next
+	}
+	e = e.AddInfo("a", 1).AddInfo("payload", strings.Repeat("x", 1<<20)).AddInfo("z", "small")
+
+	s := e.Error()
+	if len(s) > 4096 {
+		t.Fatalf("expected the encoding to be bounded, got %d bytes", len(s))
+	}
+	d := errors.JSONCodec.Decode([]byte(s))
+	if d.Depth() != 3 {
+		t.Fatalf("expected the chain to be cut after 3 errors, got %d", d.Depth())
+	}
+	if n, ok := d.RootCause().GetInt("chain_truncated"); !ok || n != 7 {
+		t.Fatalf("expected the truncation of 7 errors to be recorded, got %v", n)
+	}
+	if n, ok := d.GetInt("info_truncated"); !ok || n != 1 || d.HasInfo("payload") {
+		t.Fatalf("expected the oversized entry to be dropped, got %v", d.ErrorInfo)
+	}
+	if !d.HasInfo("a") || !d.HasInfo("z") {
+		t.Fatalf("expected the entries within the limit to be kept, got %v", d.ErrorInfo)
+	}
+	if e.Depth() != 10 || !e.HasInfo("payload") {
+		t.Fatal("expected the original error to be left untouched")
+	}
+
+	tree := errors.Constructor(codec)("batch failed").WrapsAll(e, errors.Constructor(codec)("item").AddInfo("payload", strings.Repeat("x", 1<<20)))
+	s = tree.Error()
+	if len(s) > 4096 {
+		t.Fatalf("expected the encoding of a tree to be bounded, got %d bytes", len(s))
+	}
+	d = errors.JSONCodec.Decode([]byte(s))
+	if len(d.Causes()) != 2 || d.Causes()[0].Depth() != 2 {
+		t.Fatalf("expected each branch to be cut after 3 errors, got %s", s)
+	}
+	if n, ok := d.Causes()[0].RootCause().GetInt("chain_truncated"); !ok || n != 8 {
+		t.Fatalf("expected the truncation of 8 errors to be recorded, got %v", n)
+	}
+	if d.Causes()[1].HasInfo("payload") {
+		t.Fatal("expected the information of the branches to count towards the limit")
+	}
+}
+
+func TestStrictJSONCodec(t *testing.T) {
+	payload, err := errors.StrictJSONCodec.Encode(errors.New("not found").Code(404).AddInfo("id", "42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := errors.StrictJSONCodec.Decode(payload)
+	if d.ErrorCause != "not found" || !d.HasCode(404) || !d.HasInfo("id") {
+		t.Fatalf("expected a valid payload to be decoded, got %#v", d)
+	}
+
+	extra := []byte(`{"ErrorCode":"404","ErrorCause":"not found","Extra":true}`)
+	if d := errors.JSONCodec.Decode(extra); d.ErrorCause != "not found" {
+		t.Fatalf("expected the JSONCodec to ignore the unknown field, got %q", d.ErrorCause)
+	}
+	d = errors.StrictJSONCodec.Decode(extra)
+	if !strings.HasPrefix(d.ErrorCause, "errors: unable to decode") || !strings.Contains(d.ErrorCause, "Extra") {
+		t.Fatalf("expected the unknown field to be reported, got %q", d.ErrorCause)
+	}
+	if d.HasCode(404) {
+		t.Fatal("expected the rejected payload not to be partially decoded")
+	}
+}
+
+func TestB64(t *testing.T) {
+	codec := errors.B64(errors.JSONCodec)
+	e := errors.Constructor(codec)("upstream failed\nwith a newline").Code(502).AddInfo("host", "db1 ünïcode")
+
+	header := http.Header{}
+	header.Set("X-Error", e.Error())
+	value := header.Get("X-Error")
+	for _, r := range value {
+		if r < 0x21 || r > 0x7e {
+			t.Fatalf("expected printable ASCII only, got %q", value)
+		}
+	}
+
+	d := codec.Decode([]byte(value))
+	if !errors.Equal(d, e) {
+		t.Fatalf("expected %v, got %v", e, d)
+	}
+
+	if d := codec.Decode([]byte("not base64!")); !strings.HasPrefix(d.ErrorCause, "errors: unable to decode base64") {
+		t.Fatalf("expected invalid data to be reported, got %q", d.ErrorCause)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	codec := errors.Gzip(errors.JSONCodec, 512)
+	newError := errors.Constructor(codec)
+
+	small := newError("not found").Code(404)
+	b, err := codec.Encode(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b[0] != 0 || !strings.Contains(string(b), "not found") {
+		t.Fatalf("expected a small payload to be left uncompressed, got %q", b)
+	}
+	if d := codec.Decode(b); !errors.Equal(d, small) {
+		t.Fatalf("expected %v, got %v", small, d)
+	}
+
+	large := newError("batch failed")
+	for i := 0; i < 100; i++ {
+		large.AddInfo("item_"+strconv.Itoa(i), "validation failed: value out of range")
+	}
+	b, err = codec.Encode(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := errors.JSONCodec.Encode(large)
+	if b[0] != 1 || len(b) >= len(raw)/2 {
+		t.Fatalf("expected a large payload to be compressed, got %d bytes for %d", len(b), len(raw))
+	}
+	if d := codec.Decode(b); !errors.Equal(d, large) {
+		t.Fatalf("expected the compressed payload to be decoded, got %v", d)
+	}
+
+	if d := codec.Decode([]byte{1, 'x'}); !strings.HasPrefix(d.ErrorCause, "errors: unable to decompress") {
+		t.Fatalf("expected a corrupted payload to be reported, got %q", d.ErrorCause)
+	}
+
+	var bomb bytes.Buffer
+	bomb.WriteByte(1)
+	zw := gzip.NewWriter(&bomb)
+	if _, err := zw.Write(make([]byte, 64<<20+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if d := codec.Decode(bomb.Bytes()); !strings.HasPrefix(d.ErrorCause, "errors: unable to decompress: payload larger than") {
+		t.Fatalf("expected an oversized payload to be rejected, got %.100q", d.ErrorCause)
+	}
+}
@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"context"
+	"sync"
+)
+
+// contextFields holds the context values that are harvested into the
+// information of an error by WithContext.
+var contextFields struct {
+	sync.RWMutex
+	keys map[string]interface{}
+}
+
+// RegisterContextField declares that the value stored in a context under ctxKey
+// should be recorded under the information key when an error is created with
+// NewWithContext or decorated with WithContext.
+// It is typically used for request-scoped values such as request or trace ids.
+func RegisterContextField(key string, ctxKey interface{}) {
+	contextFields.Lock()
+	defer contextFields.Unlock()
+	if contextFields.keys == nil {
+		contextFields.keys = make(map[string]interface{})
+	}
+	contextFields.keys[key] = ctxKey
+}
+
+// WithContext records the registered context values found in ctx as
+// information of the error.
+func (e *Error) WithContext(ctx context.Context) *Error {
+	contextFields.RLock()
+	defer contextFields.RUnlock()
+	for key, ctxKey := range contextFields.keys {
+		if v := ctx.Value(ctxKey); v != nil {
+			e.AddInfo(key, v)
+		}
+	}
+	return e
+}
+
+// NewWithContext returns a new Error created by New and decorated with the
+// registered context values found in ctx.
+func NewWithContext(ctx context.Context, message string) *Error {
+	return New(message).WithContext(ctx)
+}
+
+// ContextConstructor is the context-aware counterpart of Constructor. It
+// accepts the same options, the functions registered with WithContextInfoFuncs
+// receiving the context passed to the created Error creating function, which
+// allows to record request-scoped data.
+func ContextConstructor(codec Codec, options ...Option) func(context.Context, string) *Error {
+	c := newConstructorConfig(options)
+	return func(ctx context.Context, message string) *Error {
+		return c.build(ctx, codec, message)
+	}
+}
+
+// WithContextInfoFuncs decorates every Error with the information returned by
+// the given functions, given the context passed to an Error creating function
+// returned by ContextConstructor. With Constructor, they are given
+// context.Background().
+func WithContextInfoFuncs(infoHeaderFuncs ...func(context.Context) (key string, value interface{})) Option {
+	return func(c *constructorConfig) {
+		c.ctxInfoFuncs = append(c.ctxInfoFuncs, infoHeaderFuncs...)
+	}
+}
+
+// AddCtx appends e to the list unless ctx is done, in which case the list is
+// left untouched and false is returned. It allows to stop collecting the
+// results of a fan-out operation once it is cancelled.
+func (l *List) AddCtx(ctx context.Context, e error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	l.Add(e)
+	return true
+}
+
+// AddCtxErr appends the error of ctx to the list if ctx is done and the list
+// does not already hold it. It reports whether the error was appended.
+func (l *List) AddCtxErr(ctx context.Context) bool {
+	err := ctx.Err()
+	if err == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, v := range l.Values {
+		if v == err {
+			return false
+		}
+	}
+	l.add(err)
+	return true
+}
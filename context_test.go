@@ -0,0 +1,94 @@
+package errors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+type ctxKey string
+
+func TestNewWithContext(t *testing.T) {
+	errors.RegisterContextField("request_id", ctxKey("request-id"))
+	errors.RegisterContextField("trace_id", ctxKey("trace-id"))
+
+	ctx := context.WithValue(context.Background(), ctxKey("request-id"), "req-42")
+	e := errors.NewWithContext(ctx, "handler failed")
+
+	if e.ErrorInfo["request_id"] != "req-42" {
+		t.Fatalf("expected the request id to be recorded, got %v", e.ErrorInfo)
+	}
+	if _, ok := e.ErrorInfo["trace_id"]; ok {
+		t.Fatal("expected absent context values not to be recorded")
+	}
+
+	ctx = context.WithValue(ctx, ctxKey("trace-id"), "trace-7")
+	if e.WithContext(ctx).ErrorInfo["trace_id"] != "trace-7" {
+		t.Fatal("expected WithContext to record the trace id")
+	}
+}
+
+func TestContextConstructor(t *testing.T) {
+	tenant := func(ctx context.Context) (string, interface{}) {
+		return "tenant", ctx.Value(ctxKey("tenant"))
+	}
+	newError := errors.ContextConstructor(errors.JSONCodec, errors.WithContextInfoFuncs(tenant))
+	ctx := context.WithValue(context.Background(), ctxKey("tenant"), "acme")
+
+	e := newError(ctx, "quota exceeded")
+	if e.ErrorCause != "quota exceeded" || e.ErrorInfo["tenant"] != "acme" {
+		t.Fatalf("unexpected error: %#v", e)
+	}
+
+	newError = errors.ContextConstructor(errors.JSONCodec,
+		errors.WithContextInfoFuncs(tenant),
+		errors.WithDefaultCode(429),
+		errors.WithSeverity(errors.SeverityWarn),
+		errors.WithStack(),
+		errors.RequireInfo("tenant", "user"),
+	)
+	e = newError(ctx, "quota exceeded")
+	if !e.HasCode(429) || e.GetSeverity() != errors.SeverityWarn || len(e.Stack()) == 0 {
+		t.Fatalf("expected the options to be applied, got %#v", e)
+	}
+	if e.ErrorInfo["info_error"] != "missing required information: user" {
+		t.Fatalf("expected the missing information to be reported, got %v", e.ErrorInfo)
+	}
+
+	if e := errors.Constructor(errors.JSONCodec, errors.WithContextInfoFuncs(tenant))("no context"); !e.HasInfo("tenant") {
+		t.Fatal("expected Constructor to call the context-aware functions as well")
+	}
+}
+
+func TestContextConstructorInfoPanic(t *testing.T) {
+	newError := errors.ContextConstructor(errors.JSONCodec, errors.WithContextInfoFuncs(func(ctx context.Context) (string, interface{}) {
+		panic("no tenant")
+	}))
+	e := newError(context.Background(), "still created")
+	if e.ErrorInfo["info_error"] != "no tenant" {
+		t.Fatalf("expected the failure to be recorded, got %v", e.ErrorInfo)
+	}
+}
+
+func TestListAddCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := errors.NewList()
+	if !l.AddCtx(ctx, errors.New("first")) {
+		t.Fatal("expected the error to be added before the cancellation")
+	}
+	if l.AddCtxErr(ctx) {
+		t.Fatal("expected no context error to be recorded before the cancellation")
+	}
+
+	cancel()
+	if l.AddCtx(ctx, errors.New("second")) {
+		t.Fatal("expected the error not to be added after the cancellation")
+	}
+	if !l.AddCtxErr(ctx) || l.AddCtxErr(ctx) {
+		t.Fatal("expected the context error to be recorded once")
+	}
+	if l.Len() != 2 || l.Values[1] != context.Canceled {
+		t.Fatalf("unexpected values: %v", l.Values)
+	}
+}
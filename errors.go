@@ -3,10 +3,15 @@
 package errors
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/atdiar/flag"
@@ -21,20 +26,87 @@ var (
 // Calling its Error() method returns a string that corresponds to its
 // json-serialization.
 type Error struct {
-	ErrorInfo  map[string]interface{} `json:",omitempty"`
-	ErrorCode  string                 `json:"-"`
-	ErrorCause string
-	Underlying *Error `json:"ErrorSource,omitempty"`
-	codec      Codec
+	ErrorInfo     map[string]interface{} `json:",omitempty"`
+	ErrorCode     string                 `json:",omitempty"`
+	ErrorCause    string
+	ErrorSeverity Severity `json:",omitempty"`
+	ErrorStack    []Frame  `json:",omitempty"`
+	Underlying    *Error   `json:"ErrorSource,omitempty"`
+	ErrorCauses   []*Error `json:",omitempty"`
+	codec         Codec
+	mu            sync.RWMutex
+
+	// underlyingStd holds a wrapped error that is not of type Error.
+	underlyingStd error
+	// causesStd holds the branches wrapped by WrapsAll that are not of type
+	// Error. The other ones are held by ErrorCauses.
+	causesStd []error
+
+	httpStatus int
+
+	// detail holds the developer-only description set with Detail.
+	detail string
+
+	// debug overrides the DEBUG flag when it is not nil.
+	debug *bool
+	// trace holds the stack trace captured for the debug output.
+	trace []byte
+
+	// cache holds the serialization of a simple error, for the cause it was
+	// computed for.
+	cache struct {
+		cause string
+		value string
+	}
 }
 
 // Code sets an error code.
 func (e *Error) Code(c int) *Error {
 	e.ErrorCode = strconv.Itoa(c)
-	e.AddInfo("Code", c)
 	return e
 }
 
+// CodeString sets a symbolic error code such as "E_TIMEOUT".
+func (e *Error) CodeString(c string) *Error {
+	e.ErrorCode = c
+	return e
+}
+
+// GetCode returns the error code. The boolean is false if no code was set.
+func (e *Error) GetCode() (int, bool) {
+	if e == nil || e.ErrorCode == "" {
+		return 0, false
+	}
+	c, err := strconv.Atoi(e.ErrorCode)
+	if err != nil {
+		return 0, false
+	}
+	return c, true
+}
+
+// CodeInt returns the numeric error code, so that callers can switch on it
+// directly. The boolean is false if no code was set or if the code is a
+// symbolic one, set with CodeString.
+// It is equivalent to GetCode.
+func (e *Error) CodeInt() (int, bool) {
+	return e.GetCode()
+}
+
+// Coder is implemented by the errors that carry a numeric code, Error
+// included. It allows third-party code to extract a code with the standard
+// library errors.As function without depending on the Error type:
+//
+//	var c errors.Coder
+//	if stderrors.As(err, &c) {
+//		code, ok := c.CodeInt()
+//		...
+//	}
+//
+// The method is not named Code since Error uses that name to set its code.
+type Coder interface {
+	CodeInt() (int, bool)
+}
+
 // As tests whether the object implementing the error interface is of type Error.
 func As(e error) *Error {
 	err, ok := e.(*Error)
@@ -44,16 +116,130 @@ func As(e error) *Error {
 	return err
 }
 
-// Is compares errors by the
-func (e *Error) Is(code int) bool {
+// HasCode compares the error code with the one provided.
+func (e *Error) HasCode(code int) bool {
 	if e == nil {
 		return false
 	}
 	return e.ErrorCode == strconv.Itoa(code)
 }
 
+// IsAny reports whether the error code is any of the codes provided.
+func (e *Error) IsAny(codes ...int) bool {
+	for _, c := range codes {
+		if e.HasCode(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAnyInChain reports whether any Error of the chain, or of the causes merged
+// with Merge or wrapped by WrapsAll, has one of the codes provided, as IsAny
+// does.
+func (e *Error) IsAnyInChain(codes ...int) bool {
+	found := false
+	e.walkTree(func(err *Error) {
+		found = found || err.IsAny(codes...)
+	})
+	return found
+}
+
+// IsCode compares the error code with the symbolic code provided.
+func (e *Error) IsCode(code string) bool {
+	if e == nil {
+		return false
+	}
+	return e.ErrorCode == code
+}
+
+// Is compares errors by their code. If the target has no code, their causes are
+// compared instead.
+// It allows the standard library errors.Is function to match an Error against a
+// sentinel value anywhere in a chain or a tree of errors.
+func (e *Error) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+	t := As(target)
+	if t == nil {
+		return false
+	}
+	if t.ErrorCode != "" {
+		return e.ErrorCode == t.ErrorCode
+	}
+	return t.ErrorCause != "" && e.ErrorCause == t.ErrorCause
+}
+
+// Equal reports whether a and b, as well as their chains of underlying errors
+// and their merged causes, have the same codes, causes and information. The
+// information stored under the given keys is ignored, in addition to the
+// volatile information stored under the "date", "line", "file" and "trace"
+// keys.
+// It allows tests to assert on the stable parts of errors.
+func Equal(a, b *Error, ignoreKeys ...string) bool {
+	ignored := map[string]bool{"date": true, "line": true, "file": true, "trace": true}
+	for _, k := range ignoreKeys {
+		ignored[k] = true
+	}
+	return equalChains(a, b, ignored)
+}
+
+// equalChains reports whether the chains of a and b are equal, ignoring the
+// information stored under the ignored keys.
+func equalChains(a, b *Error, ignored map[string]bool) bool {
+	var chainA, chainB []*Error
+	a.Walk(func(err *Error) bool { chainA = append(chainA, err); return true })
+	b.Walk(func(err *Error) bool { chainB = append(chainB, err); return true })
+	if len(chainA) != len(chainB) {
+		return false
+	}
+	for i := range chainA {
+		if !chainA[i].equal(chainB[i], ignored) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Error) equal(o *Error, ignored map[string]bool) bool {
+	if e == o {
+		return true
+	}
+	if e.ErrorCode != o.ErrorCode || e.ErrorCause != o.ErrorCause || len(e.ErrorCauses) != len(o.ErrorCauses) {
+		return false
+	}
+	for i := range e.ErrorCauses {
+		if !equalChains(e.ErrorCauses[i], o.ErrorCauses[i], ignored) {
+			return false
+		}
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for k, v := range e.ErrorInfo {
+		if ignored[k] {
+			continue
+		}
+		ov, ok := o.ErrorInfo[k]
+		if !ok || !reflect.DeepEqual(v, ov) {
+			return false
+		}
+	}
+	for k := range o.ErrorInfo {
+		if _, ok := e.ErrorInfo[k]; !ok && !ignored[k] {
+			return false
+		}
+	}
+	return true
+}
+
 // AddInfo allows to prepend information to an error string.
+// It is safe to decorate the same error from several goroutines.
 func (e *Error) AddInfo(key string, value interface{}) *Error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	if e.ErrorInfo == nil {
 		e.ErrorInfo = make(map[string]interface{})
 	}
@@ -61,6 +247,380 @@ func (e *Error) AddInfo(key string, value interface{}) *Error {
 	return e
 }
 
+// AddInfos records every key/value pair of m as information of the error,
+// replacing the values already stored under the same keys.
+// It is safe to decorate the same error from several goroutines.
+func (e *Error) AddInfos(m map[string]interface{}) *Error {
+	if len(m) == 0 {
+		return e
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.ErrorInfo == nil {
+		e.ErrorInfo = make(map[string]interface{}, len(m))
+	}
+	for k, v := range m {
+		e.ErrorInfo[k] = v
+	}
+	return e
+}
+
+// WithFields is an alias of AddInfos.
+func (e *Error) WithFields(fields map[string]interface{}) *Error {
+	return e.AddInfos(fields)
+}
+
+// AddInfoFrom copies the information held by other into the error. Keys that
+// the error already holds are kept as they are.
+// Unlike Wraps, it does not link both errors: the information is merged.
+func (e *Error) AddInfoFrom(other *Error) *Error {
+	return e.mergeInfo(other, false)
+}
+
+// OverwriteInfoFrom copies the information held by other into the error.
+// Unlike AddInfoFrom, the values of other replace those of the error for the
+// keys they both hold.
+func (e *Error) OverwriteInfoFrom(other *Error) *Error {
+	return e.mergeInfo(other, true)
+}
+
+// InheritInfo copies the information stored under the given keys by the
+// wrapped errors up to the error, e.g. a request id recorded at the root of a
+// chain, so that it is found at the top without walking the chain:
+//
+//	errors.New("checkout failed").Wraps(cause).InheritInfo("request_id")
+//
+// Keys that the error already holds are kept as they are. Otherwise, the value
+// of the outermost wrapped error holding the key wins, as with GetInfo.
+func (e *Error) InheritInfo(keys ...string) *Error {
+	for _, k := range keys {
+		if e.HasInfo(k) {
+			continue
+		}
+		if v, ok := e.Underlying.GetInfo(k); ok {
+			e.AddInfo(k, v)
+		}
+	}
+	return e
+}
+
+func (e *Error) mergeInfo(other *Error, overwrite bool) *Error {
+	if other == nil || other == e {
+		return e
+	}
+	other.mu.RLock()
+	info := make(map[string]interface{}, len(other.ErrorInfo))
+	for k, v := range other.ErrorInfo {
+		info[k] = v
+	}
+	other.mu.RUnlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.ErrorInfo == nil && len(info) > 0 {
+		e.ErrorInfo = make(map[string]interface{}, len(info))
+	}
+	for k, v := range info {
+		if _, ok := e.ErrorInfo[k]; ok && !overwrite {
+			continue
+		}
+		e.ErrorInfo[k] = v
+	}
+	return e
+}
+
+// HasInfo reports whether the error holds information under key. The chain of
+// underlying errors is not consulted. A nil error holds no information.
+func (e *Error) HasInfo(key string) bool {
+	if e == nil {
+		return false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.ErrorInfo[key]
+	return ok
+}
+
+// DeleteInfo removes the information stored under the given keys, if any.
+// The chain of underlying errors is left untouched. A nil error is returned as
+// is.
+func (e *Error) DeleteInfo(keys ...string) *Error {
+	if e == nil {
+		return e
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, k := range keys {
+		delete(e.ErrorInfo, k)
+	}
+	return e
+}
+
+// Clone returns a deep copy of an error. The information map and the chain of
+// underlying errors are copied so that the clone can be modified without
+// affecting the original error.
+// An Error reachable through several merged causes or branches is copied once,
+// and the copy is shared as in the original.
+// If the chain is cyclic, the copy stops before the first repeated Error.
+func (e *Error) Clone() *Error {
+	return e.clone(make(map[*Error]*Error))
+}
+
+// clone records the copy of each Error in cloned. An Error that is still being
+// copied maps to nil, so that wrapping it again, i.e. a cycle, is left out.
+func (e *Error) clone(cloned map[*Error]*Error) *Error {
+	if e == nil {
+		return nil
+	}
+	if c, ok := cloned[e]; ok {
+		return c
+	}
+	cloned[e] = nil
+	c := e.copyLink()
+	c.Underlying = e.Underlying.clone(cloned)
+	for _, m := range e.ErrorCauses {
+		if mc := m.clone(cloned); mc != nil {
+			c.ErrorCauses = append(c.ErrorCauses, mc)
+		}
+	}
+	c.causesStd = append([]error(nil), e.causesStd...)
+	cloned[e] = c
+	return c
+}
+
+// copyLink returns a copy of the error that does not wrap any other Error, nor
+// hold merged causes.
+func (e *Error) copyLink() *Error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	c := &Error{
+		ErrorCode:     e.ErrorCode,
+		ErrorCause:    e.ErrorCause,
+		ErrorSeverity: e.ErrorSeverity,
+		ErrorStack:    e.ErrorStack,
+		codec:         e.codec,
+		underlyingStd: e.underlyingStd,
+		httpStatus:    e.httpStatus,
+		detail:        e.detail,
+		debug:         e.debug,
+		trace:         e.trace,
+	}
+	if e.ErrorInfo != nil {
+		c.ErrorInfo = make(map[string]interface{}, len(e.ErrorInfo))
+		for k, v := range e.ErrorInfo {
+			c.ErrorInfo[k] = v
+		}
+	}
+	return c
+}
+
+// Walk calls fn for each Error of the chain, from the receiver to the root
+// cause. It stops as soon as fn returns false.
+// Each Error is visited once: if the chain is cyclic, the walk stops before
+// the first repeated Error.
+func (e *Error) Walk(fn func(*Error) bool) {
+	var visited map[*Error]bool // only allocated for chains of errors
+	for err := e; err != nil && !visited[err]; err = err.Underlying {
+		if err.Underlying != nil {
+			if visited == nil {
+				visited = make(map[*Error]bool)
+			}
+			visited[err] = true
+		}
+		if !fn(err) {
+			return
+		}
+	}
+}
+
+// walkTree calls fn for each Error of the chain and, recursively, of the chains
+// of the causes merged with Merge or wrapped by WrapsAll. Each Error is visited
+// once.
+func (e *Error) walkTree(fn func(*Error)) {
+	visited := make(map[*Error]bool)
+	var walk func(*Error)
+	walk = func(err *Error) {
+		for ; err != nil && !visited[err]; err = err.Underlying {
+			visited[err] = true
+			fn(err)
+			for _, m := range err.ErrorCauses {
+				walk(m)
+			}
+		}
+	}
+	walk(e)
+}
+
+// cyclic reports whether an Error of the chain, or of the causes merged with
+// Merge or wrapped by WrapsAll, wraps itself, directly or not.
+func (e *Error) cyclic() bool {
+	if e.Underlying == nil && len(e.ErrorCauses) == 0 {
+		return false
+	}
+	onPath := make(map[*Error]bool) // false once the errors it wraps are checked
+	var check func(*Error) bool
+	check = func(err *Error) bool {
+		if err == nil {
+			return false
+		}
+		if p, seen := onPath[err]; seen {
+			return p
+		}
+		onPath[err] = true
+		if check(err.Underlying) {
+			return true
+		}
+		for _, m := range err.ErrorCauses {
+			if check(m) {
+				return true
+			}
+		}
+		onPath[err] = false
+		return false
+	}
+	return check(e)
+}
+
+// Merge returns a new error standing for two independent failures of the same
+// operation, e.g. when both a primary and a fallback attempt failed. Neither
+// error is the cause of the other: both are held as causes of the new error,
+// in order, and can be retrieved with Causes. The cause of the new error is
+// made of their causes separated by a semicolon.
+// The new error uses the codec of the receiver. If either error is nil, the
+// other one is returned as is.
+func (e *Error) Merge(other *Error) *Error {
+	if e == nil {
+		return other
+	}
+	if other == nil {
+		return e
+	}
+	m := &Error{ErrorCause: e.ErrorCause + "; " + other.ErrorCause, ErrorCauses: []*Error{e, other}, codec: JSONCodec}
+	if e.codec.Encode != nil {
+		m.codec = e.codec
+	}
+	notifyCreate(m)
+	return m
+}
+
+// Causes returns the errors merged with Merge, if any.
+func (e *Error) Causes() []*Error {
+	if e == nil {
+		return nil
+	}
+	return e.ErrorCauses
+}
+
+// Branches returns the errors wrapped by WrapsAll or merged with Merge, if any.
+// The branches of type Error come first.
+func (e *Error) Branches() []error {
+	if e == nil || len(e.ErrorCauses)+len(e.causesStd) == 0 {
+		return nil
+	}
+	branches := make([]error, 0, len(e.ErrorCauses)+len(e.causesStd))
+	for _, c := range e.ErrorCauses {
+		branches = append(branches, c)
+	}
+	return append(branches, e.causesStd...)
+}
+
+// allCauses returns the branches of the error as Errors, those that are not of
+// type Error being replaced by an Error holding their message, for the
+// purpose of serialization.
+func (e *Error) allCauses() []*Error {
+	if len(e.causesStd) == 0 {
+		return e.ErrorCauses
+	}
+	causes := append([]*Error(nil), e.ErrorCauses...)
+	for _, c := range e.causesStd {
+		causes = append(causes, &Error{ErrorCause: c.Error()})
+	}
+	return causes
+}
+
+// Depth returns the number of Errors in the chain, including the receiver.
+// An error that does not wrap any Error has a depth of 1.
+func (e *Error) Depth() int {
+	n := 0
+	e.Walk(func(*Error) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Fields returns a flat map of the information held by the error and its chain
+// of underlying errors. When a key is set at several levels, the value of the
+// outermost error wins.
+// The code and cause of the receiver are stored under the reserved
+// "ErrorCode" and "ErrorCause" keys.
+func (e *Error) Fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	if e == nil {
+		return fields
+	}
+	e.Walk(func(err *Error) bool {
+		err.mu.RLock()
+		for k, v := range err.ErrorInfo {
+			if _, ok := fields[k]; !ok {
+				fields[k] = v
+			}
+		}
+		err.mu.RUnlock()
+		return true
+	})
+	if e.ErrorCode != "" {
+		fields["ErrorCode"] = e.ErrorCode
+	}
+	fields["ErrorCause"] = e.ErrorCause
+	return fields
+}
+
+// GetInfo returns the information stored under key by the error or, failing
+// that, by the first error of its chain holding it.
+func (e *Error) GetInfo(key string) (interface{}, bool) {
+	var value interface{}
+	found := false
+	e.Walk(func(err *Error) bool {
+		err.mu.RLock()
+		value, found = err.ErrorInfo[key]
+		err.mu.RUnlock()
+		return !found
+	})
+	return value, found
+}
+
+// GetString returns the information stored under key, as returned by GetInfo,
+// if it is a string.
+func (e *Error) GetString(key string) (string, bool) {
+	v, _ := e.GetInfo(key)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// GetInt returns the information stored under key, as returned by GetInfo,
+// if it is an integer. Integers decoded as floating point numbers, e.g. by the
+// JSONCodec, or as text, e.g. by the XMLCodec, are converted.
+func (e *Error) GetInt(key string) (int, bool) {
+	v, ok := e.GetInfo(key)
+	if !ok {
+		return 0, false
+	}
+	i, ok := toInt(v)
+	return int(i), ok
+}
+
+// GetBool returns the information stored under key, as returned by GetInfo,
+// if it is a boolean. Booleans decoded as text, e.g. by the XMLCodec, are
+// parsed.
+func (e *Error) GetBool(key string) (bool, bool) {
+	v, ok := e.GetInfo(key)
+	if !ok {
+		return false, false
+	}
+	return toBool(v)
+}
+
 // Retrieve will extract an Error object from an error interface.
 func (e *Error) Retrieve(E error) *Error {
 	if E == nil {
@@ -72,61 +632,487 @@ func (e *Error) Retrieve(E error) *Error {
 	return e.codec.Decode([]byte(E.Error()))
 }
 
+// Wraps returns a copy of the error whose underlying error is E.
+// The receiver is left untouched so that it can be reused as a template.
+// If E is not of type Error, it is kept as is and can be retrieved by Source.
+// If E is a List, its values are wrapped as branches, as with WrapsAll.
+// An error cannot wrap itself: in that case, the copy keeps the underlying
+// error of the receiver.
+// Only the receiver is copied: the errors it wraps, if any, are shared.
 func (e *Error) Wraps(E error) *Error {
-	ne := *e
-	err := ne.Retrieve(E)
-	if e == err {
-		return e
+	if l, ok := E.(*List); ok && l != nil {
+		return e.WrapsAll(l.values()...)
+	}
+	ne := e.copyLink()
+	ne.ErrorCauses = append([]*Error(nil), e.ErrorCauses...)
+	ne.causesStd = append([]error(nil), e.causesStd...)
+	err, ok := E.(*Error)
+	switch {
+	case ok && e == err:
+		ne.Underlying = e.Underlying
+	case ok:
+		ne.Underlying = err
+		ne.underlyingStd = nil
+	default:
+		ne.underlyingStd = E
+	}
+	return ne
+}
+
+// WrapsAll returns a copy of the error wrapping every one of errs, so that the
+// errors form a tree rather than a chain. The wrapped errors replace the
+// branches of the receiver, if any, and can be retrieved with Branches. Nil
+// errors and the receiver itself are skipped. If a single error remains, it is
+// wrapped as the underlying error, as with Wraps.
+// The receiver is left untouched so that it can be reused as a template.
+func (e *Error) WrapsAll(errs ...error) *Error {
+	branches := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err == nil || err == error(e) {
+			continue
+		}
+		if ee, ok := err.(*Error); ok && ee == nil {
+			continue
+		}
+		branches = append(branches, err)
+	}
+	if len(branches) == 1 {
+		return e.Wraps(branches[0])
+	}
+	ne := e.Clone()
+	ne.Underlying = nil
+	ne.underlyingStd = nil
+	ne.ErrorCauses = nil
+	ne.causesStd = nil
+	for _, b := range branches {
+		if ee, ok := b.(*Error); ok {
+			ne.ErrorCauses = append(ne.ErrorCauses, ee)
+			continue
+		}
+		ne.causesStd = append(ne.causesStd, b)
+	}
+	return ne
+}
+
+// Annotate returns a new error whose cause is msg and whose underlying error
+// is the receiver, so that the chain reads from the most general context to
+// the root cause, e.g. "loading config: open config.json: permission denied".
+// The new error uses the codec of the receiver.
+func (e *Error) Annotate(msg string) *Error {
+	a := &Error{ErrorCause: msg, Underlying: e, codec: JSONCodec}
+	if e != nil && e.codec.Encode != nil {
+		a.codec = e.codec
+	}
+	notifyCreate(a)
+	return a
+}
+
+// FullMessage returns the causes of the chain of errors, from the outermost to
+// the innermost, separated by colons, e.g. "outer: inner: root".
+// It is the output of the %v verb.
+func (e *Error) FullMessage() string {
+	if e == nil {
+		return ""
 	}
-	e.Underlying = err
+	return e.message()
+}
+
+// Ellipsis marks the end of a cause shortened by TruncateCause.
+const Ellipsis = "…"
+
+// TruncateCause shortens the cause of the error to its first max runes,
+// followed by the Ellipsis, if it is longer than that. It keeps the errors
+// holding enormous messages, such as driver dumps, readable in logs.
+// A max lower than 1 leaves the cause untouched.
+func (e *Error) TruncateCause(max int) *Error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ErrorCause = truncate(e.ErrorCause, max)
+	return e
+}
+
+// TruncateCauses shortens the cause of every Error of the chain, and of the
+// causes merged with Merge or wrapped by WrapsAll, as TruncateCause does.
+func (e *Error) TruncateCauses(max int) *Error {
+	e.walkTree(func(err *Error) {
+		err.TruncateCause(max)
+	})
 	return e
 }
 
+// truncate returns the first max runes of s, followed by the Ellipsis, if s is
+// longer than that.
+func truncate(s string, max int) string {
+	if max < 1 || len(s) <= max {
+		return s
+	}
+	n := 0
+	for i := range s {
+		if n == max {
+			return s[:i] + Ellipsis
+		}
+		n++
+	}
+	return s
+}
+
+// Unwrap returns the underlying error, if any, followed by the branches of the
+// error, as returned by Branches. It allows the standard library errors.Is and
+// errors.As functions to walk the whole tree of errors, as they do for the
+// errors joined by errors.Join.
+// Since errors.Unwrap only follows the errors returning a single error, Source
+// should be used instead to walk the chain of underlying errors.
+func (e *Error) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	branches := e.Branches()
+	src := e.Source()
+	if src == nil {
+		return branches
+	}
+	return append([]error{src}, branches...)
+}
+
+// Source returns the underlying error if any, whether it is of type Error or
+// not. Unlike Unwrap, it leaves the branches out.
+func (e *Error) Source() error {
+	if e == nil {
+		return nil
+	}
+	if e.Underlying != nil {
+		return e.Underlying
+	}
+	if e.underlyingStd != nil {
+		return e.underlyingStd
+	}
+	return nil
+}
+
+// RootCause returns the innermost Error of the chain. An error that does not
+// wrap any other Error is its own root cause.
+func (e *Error) RootCause() *Error {
+	if e == nil {
+		return nil
+	}
+	var root *Error
+	e.Walk(func(err *Error) bool {
+		root = err
+		return true
+	})
+	return root
+}
+
+// Cause returns the innermost error of the chain. It is either the root cause
+// or the error that is not of type Error wrapped by the root cause.
+func (e *Error) Cause() error {
+	root := e.RootCause()
+	if root == nil {
+		return nil
+	}
+	if root.underlyingStd != nil {
+		return root.underlyingStd
+	}
+	return root
+}
+
+// Debug enables or disables the debug output of this error only, overriding
+// the package-level DEBUG flag. When debugging is enabled, the Error method
+// appends a stack trace to the serialized error.
+// If no trace was captured when the error was created, it is captured when
+// debugging is enabled.
+func (e *Error) Debug(enabled bool) *Error {
+	e.debug = &enabled
+	if enabled && e.trace == nil {
+		e.trace = captureStack(false)
+	}
+	return e
+}
+
+// detailKey is the information key under which the details of an error are
+// serialized when debugging is enabled. It is reserved, as the names of the
+// fields of Error are, so that it does not collide with the information keys
+// chosen by users, such as "detail".
+const detailKey = "ErrorDetail"
+
+// Detail sets a description of the error meant for developers only, such as
+// the internal condition that caused it, while the cause remains the message
+// that can be shown to users.
+// The detail is only serialized, under the reserved "ErrorDetail" information
+// key, when debugging is enabled for the error, and is always dropped by a
+// Sanitized codec. It is part of the %+v output.
+func (e *Error) Detail(detail string) *Error {
+	e.mu.Lock()
+	e.detail = detail
+	e.mu.Unlock()
+	return e
+}
+
+// GetDetail returns the detail set with Detail or, failing that, the detail
+// of an error decoded from its debug serialization, if any.
+func (e *Error) GetDetail() string {
+	if e == nil {
+		return ""
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.detail == "" {
+		d, _ := e.ErrorInfo[detailKey].(string)
+		return d
+	}
+	return e.detail
+}
+
+// addDetails records the details of the errors of the chain, and of the
+// merged causes, as information, in place.
+func (e *Error) addDetails() {
+	e.walkTree(func(err *Error) {
+		if err.detail != "" {
+			if err.ErrorInfo == nil {
+				err.ErrorInfo = make(map[string]interface{})
+			}
+			err.ErrorInfo[detailKey] = err.detail
+		}
+	})
+}
+
+// captureTrace stores the stack trace of the current goroutine, used by the
+// debug output, if the DEBUG flag is set. It is called by the constructors so
+// that the trace reflects the origin of the error rather than the place where
+// it is logged. The other goroutines are left out: dumping them all for every
+// error would be costly. PrintTraceAll records them when needed.
+func (e *Error) captureTrace() {
+	if DEBUG.IsTrue() {
+		e.trace = captureStack(false)
+	}
+}
+
+// debugTrace returns the stored stack trace or, if it was not captured, the
+// current one.
+func (e *Error) debugTrace() string {
+	if e.trace != nil {
+		return string(e.trace)
+	}
+	return string(captureStack(false))
+}
+
+// debugging reports whether the debug output is enabled for the error.
+func (e *Error) debugging() bool {
+	if e != nil && e.debug != nil {
+		return *e.debug
+	}
+	return DEBUG.IsTrue()
+}
+
 // Error is the method allowing the Error type to implement the standard error
 // interface.
+// The codecs of this package hold the read lock of each Error while reading
+// it, so that the error is protected from concurrent modifications while it is
+// encoded. A copy of the error is encoded instead when it has to be modified
+// beforehand, i.e. when a redaction function is registered with SetRedactor or
+// when the details are added in debug mode, and when the chain is cyclic, so
+// that it is encoded up to the first repeated Error.
 func (e *Error) Error() string {
+	if s, ok := e.cached(); ok {
+		return s
+	}
 	var strErr string
-	res, err := e.codec.Encode(e)
+	debugging := e.debugging()
+	c := e
+	if debugging || redacting() || e.cyclic() {
+		c = e.Clone()
+		c.applyRedactor()
+		if debugging {
+			c.addDetails()
+		}
+	}
+	e.mu.RLock()
+	cause, simple := e.ErrorCause, e.simple()
+	e.mu.RUnlock()
+	res, err := e.codec.Encode(c)
 	if err != nil {
 		strErr = err.Error()
-		if DEBUG.IsTrue() {
-			// create stacktrace and append it
-			buf := make([]byte, 1024)
-			runtime.Stack(buf, true)
-			strErr = strErr + "\n\n" + fmt.Sprint(string(buf))
+		if debugging {
+			strErr = strErr + "\n\n" + e.debugTrace()
 		}
 		return strErr
 	}
 	strErr = string(res)
-	if DEBUG.IsTrue() {
-		// create stacktrace and append it
-		buf := make([]byte, 1024)
-		runtime.Stack(buf, true)
-		strErr = strErr + "\n\nTRACE===========================================\n" + fmt.Sprint(string(buf)) + "\n\n"
+	if debugging {
+		strErr = strErr + "\n\nTRACE===========================================\n" + e.debugTrace() + "\n\n"
+		return strErr
+	}
+	if simple {
+		e.mu.Lock()
+		e.cache.cause = cause
+		e.cache.value = strErr
+		e.mu.Unlock()
 	}
 	return strErr
 }
 
+// simple reports whether the error only holds a cause, in which case its
+// serialization can be cached.
+func (e *Error) simple() bool {
+	return len(e.ErrorInfo) == 0 && e.ErrorCode == "" && e.ErrorSeverity == 0 &&
+		e.ErrorStack == nil && e.Underlying == nil && e.underlyingStd == nil &&
+		len(e.ErrorCauses) == 0 && len(e.causesStd) == 0
+}
+
+// cached returns the cached serialization of a simple error, if it is still
+// valid. It allows to serialize such errors repeatedly without allocating.
+func (e *Error) cached() (string, bool) {
+	if e == nil || e.debugging() {
+		return "", false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.cache.value == "" || e.cache.cause != e.ErrorCause || !e.simple() {
+		return "", false
+	}
+	return e.cache.value, true
+}
+
+// String returns a concise, human readable description of the error made of
+// its code, if any, and its cause, e.g. "[404] not found".
+// Unlike Error, it does not include the information, the chain of underlying
+// errors or any serialization: it is meant for humans rather than for
+// machines.
 func (e *Error) String() string {
-	return e.ErrorCause
+	if e == nil {
+		return "<nil>"
+	}
+	if e.ErrorCode == "" {
+		return e.ErrorCause
+	}
+	return "[" + e.ErrorCode + "] " + e.ErrorCause
 }
 
 // Constructor is a function that allows to create an Error creating function.
-// A set of functions that return information key/value pairs can be specified.
-// Any Error created will subsequently be decorated with information.
-func Constructor(codec Codec, infoHeaderFuncs ...func() (key string, value interface{})) func(string) *Error {
+// Options specify the policy applied to every Error created, such as the
+// functions that return information key/value pairs decorating the errors, a
+// default code or the capture of the stack trace.
+func Constructor(codec Codec, options ...Option) func(string) *Error {
+	c := newConstructorConfig(options)
 	return func(message string) *Error {
-		e := Error{nil, "", message, nil, codec}
-		if len(infoHeaderFuncs) == 0 {
-			return &e
-		}
+		return c.build(context.Background(), codec, message)
+	}
+}
+
+// build creates an Error according to the configuration. The context is passed
+// to the functions registered with WithContextInfoFuncs.
+func (c *constructorConfig) build(ctx context.Context, codec Codec, message string) *Error {
+	e := Error{ErrorCause: message, ErrorCode: c.code, ErrorSeverity: c.severity, codec: codec}
+	e.captureTrace()
+	if c.stack {
+		e.ErrorStack = callers()
+	}
+	if len(c.infoHeaderFuncs) > 0 || len(c.ctxInfoFuncs) > 0 || len(c.required) > 0 {
 		e.ErrorInfo = make(map[string]interface{})
-		for _, f := range infoHeaderFuncs {
-			name, value := f()
-			e.ErrorInfo[name] = value
+		for _, f := range c.infoHeaderFuncs {
+			e.addInfoFunc(f)
 		}
-		return &e
+		for _, f := range c.ctxInfoFuncs {
+			f := f
+			e.addInfoFunc(func() (string, interface{}) { return f(ctx) })
+		}
+		var missing []string
+		for _, k := range c.required {
+			if _, ok := e.ErrorInfo[k]; !ok {
+				missing = append(missing, k)
+			}
+		}
+		if len(missing) > 0 {
+			e.addInfoError("missing required information: " + strings.Join(missing, ", "))
+		}
+	}
+	notifyCreate(&e)
+	return &e
+}
+
+// Option configures the errors created by a Constructor.
+type Option func(*constructorConfig)
+
+type constructorConfig struct {
+	infoHeaderFuncs []func() (key string, value interface{})
+	ctxInfoFuncs    []func(context.Context) (key string, value interface{})
+	code            string
+	severity        Severity
+	stack           bool
+	required        []string
+}
+
+func newConstructorConfig(options []Option) *constructorConfig {
+	c := new(constructorConfig)
+	for _, opt := range options {
+		opt(c)
 	}
+	return c
+}
+
+// WithInfoFuncs decorates every Error with the information returned by the
+// given functions, such as PrintFile, PrintFunc or PrintLine.
+func WithInfoFuncs(infoHeaderFuncs ...func() (key string, value interface{})) Option {
+	return func(c *constructorConfig) {
+		c.infoHeaderFuncs = append(c.infoHeaderFuncs, infoHeaderFuncs...)
+	}
+}
+
+// WithDefaultCode sets the code of every Error. It can be changed afterwards
+// with the Code method.
+func WithDefaultCode(code int) Option {
+	return func(c *constructorConfig) {
+		c.code = strconv.Itoa(code)
+	}
+}
+
+// WithSeverity sets the severity of every Error.
+func WithSeverity(level Severity) Option {
+	return func(c *constructorConfig) {
+		c.severity = level
+	}
+}
+
+// RequireInfo declares information keys that every Error must hold once the
+// information functions have run. If any of them is missing, the failure is
+// recorded under the "info_error" key, as for an information function that
+// panics, so that a misconfigured constructor is caught early.
+func RequireInfo(keys ...string) Option {
+	return func(c *constructorConfig) {
+		c.required = append(c.required, keys...)
+	}
+}
+
+// WithStack records the stack trace in every Error, as the WithStack method
+// does.
+func WithStack() Option {
+	return func(c *constructorConfig) {
+		c.stack = true
+	}
+}
+
+// addInfoFunc records the information returned by f. If f panics, the failure
+// is recorded under the "info_error" key instead, so that the creation of the
+// error does not fail because of a faulty information function.
+func (e *Error) addInfoFunc(f func() (key string, value interface{})) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		e.addInfoError(fmt.Sprint(r))
+	}()
+	name, value := f()
+	e.ErrorInfo[name] = value
+}
+
+// addInfoError records a failure to gather the information of the error under
+// the "info_error" key, after the failures already recorded, if any.
+func (e *Error) addInfoError(msg string) {
+	if prev, ok := e.ErrorInfo["info_error"].(string); ok {
+		msg = prev + "; " + msg
+	}
+	e.ErrorInfo["info_error"] = msg
 }
 
 // Codec defines a pair of functions used to marshall/unmarshall an object of
@@ -141,23 +1127,110 @@ func NewCodec(Enc func(interface{}) ([]byte, error), Dec func([]byte) *Error) Co
 	return Codec{Enc, Dec}
 }
 
+// Decoded returns an Error whose cause is message and which uses codec. Unlike
+// the errors produced by a Constructor, it holds no information, no trace is
+// captured for the DEBUG flag and the functions registered with OnCreate are
+// not called: it is meant for the decoders of codecs, and the conversions from
+// other error representations, which rebuild an existing error rather than
+// create a new one.
+func Decoded(codec Codec, message string) *Error {
+	return &Error{ErrorCause: message, codec: codec}
+}
+
 // toJSON will enable the encoding of the bare error string and the additional
 // information as a JSON string.
+// The output is deterministic: the fields of an Error are always written in the
+// same order and the information entries are sorted by key.
 func toJSON(i interface{}) ([]byte, error) {
 	return json.MarshalIndent(i, "", " ")
 }
 
+// toCompactJSON encodes an Error as a single line JSON string.
+func toCompactJSON(i interface{}) ([]byte, error) {
+	return json.Marshal(i)
+}
+
+// jsonError has the fields of Error but not its methods, which allows to use
+// the default JSON encoding of its fields within MarshalJSON and UnmarshalJSON.
+type jsonError Error
+
+// MarshalJSON implements the json.Marshaler interface, so that an Error can be
+// embedded in a larger structure and still be encoded as a nested JSON object.
+// The JSON encoding is used, whichever codec the error was created with.
+// A wrapped error that is not of type Error is encoded as an Error holding its
+// message.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if (e.Underlying == nil && e.underlyingStd != nil) || len(e.causesStd) > 0 {
+		underlying := e.Underlying
+		if underlying == nil && e.underlyingStd != nil {
+			underlying = &Error{ErrorCause: e.underlyingStd.Error()}
+		}
+		return json.Marshal(struct {
+			*jsonError
+			Underlying  *Error   `json:"ErrorSource,omitempty"`
+			ErrorCauses []*Error `json:",omitempty"`
+		}{(*jsonError)(e), underlying, e.allCauses()})
+	}
+	return json.Marshal((*jsonError)(e))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The decoded error
+// uses the JSONCodec.
+func (e *Error) UnmarshalJSON(b []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := json.Unmarshal(b, (*jsonError)(e)); err != nil {
+		return err
+	}
+	e.codec = JSONCodec
+	return nil
+}
+
 // fromJSON enables the decoding of an error string into an Error object.
 func fromJSON(b []byte) *Error {
 	var e Error
 	err := json.Unmarshal(b, &e)
 	if err != nil {
+		// The codec is built anew: referring to JSONCodec here would make its
+		// initialization depend on itself.
 		e.ErrorCause = string(b)
+		e.codec = NewCodec(toJSON, fromJSON)
 		return &e
 	}
 	return &e
 }
 
+// fromCompactJSON decodes an error string as fromJSON does, but every decoded
+// Error keeps the CompactJSONCodec, so that it is printed on a single line.
+func fromCompactJSON(b []byte) *Error {
+	e := fromJSON(b)
+	c := NewCodec(toCompactJSON, fromCompactJSON)
+	e.walkTree(func(err *Error) {
+		err.codec = c
+	})
+	return e
+}
+
+// fromStrictJSON decodes an error string into an Error object, rejecting the
+// unknown top-level fields and the data trailing the JSON object. On failure,
+// the cause of the returned Error describes it.
+func fromStrictJSON(b []byte) *Error {
+	var e Error
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	err := d.Decode((*jsonError)(&e))
+	if err == nil && d.More() {
+		err = fmt.Errorf("unexpected data after the error")
+	}
+	if err != nil {
+		return &Error{ErrorCause: "errors: unable to decode: " + err.Error(), codec: JSONCodec}
+	}
+	e.codec = JSONCodec
+	return &e
+}
+
 /* JSONCodec is an Error Encoder/Decoder object.
 var JSONCodec Codec
 
@@ -168,90 +1241,674 @@ var New func(message string) *Error
 // Defaults */
 var (
 	JSONCodec = NewCodec(toJSON, fromJSON)
-	New       = Constructor(JSONCodec, PrintFile, PrintFunc, PrintLine)
+
+	// CompactJSONCodec encodes errors as single line JSON strings, which suits
+	// logs holding one error per line.
+	CompactJSONCodec = NewCodec(toCompactJSON, fromCompactJSON)
+
+	// StrictJSONCodec encodes errors as the JSONCodec does but, unlike it,
+	// reports the payloads holding unknown top-level fields as decoding
+	// failures instead of silently ignoring them.
+	StrictJSONCodec = NewCodec(toJSON, fromStrictJSON)
+
+	// New can be replaced in order to change the default codec, for instance:
+	//  New = Constructor(CompactJSONCodec, WithInfoFuncs(PrintFile, PrintFunc, PrintLine))
+	New = Constructor(JSONCodec, WithInfoFuncs(PrintFile, PrintFunc, PrintLine))
 )
 
+// Newf creates an Error with New, whose cause is formatted according to a
+// format specifier, as with fmt.Sprintf.
+func Newf(format string, args ...interface{}) *Error {
+	return New(fmt.Sprintf(format, args...))
+}
+
+// Wrapf creates an Error with New, whose cause is formatted according to a
+// format specifier and whose underlying error is err, in the manner of
+// fmt.Errorf with the %w verb. The wrapped error can be retrieved by Source.
+// The functions registered with OnCreate are called once err is wrapped.
+func Wrapf(err error, format string, args ...interface{}) *Error {
+	var e *Error
+	withoutHooks(func() {
+		e = New(fmt.Sprintf(format, args...))
+	})
+	if u, ok := err.(*Error); ok {
+		e.Underlying = u
+	} else {
+		e.underlyingStd = err
+	}
+	notifyCreate(e)
+	return e
+}
+
+// Wrap replaces the error pointed to by errp, if it is not nil, by an Error
+// created with New whose cause is msg and whose underlying error is the
+// original one, as Wrapf does. It is meant to annotate every error returned
+// by a function with a named result:
+//
+//	func load(path string) (err error) {
+//		defer errors.Wrap(&err, "loading "+path)
+//		...
+//	}
+func Wrap(errp *error, msg string) {
+	if errp == nil || *errp == nil {
+		return
+	}
+	*errp = Wrapf(*errp, "%s", msg)
+}
+
+// nowFunc returns the current time for PrintDate, PrintTimestamp and
+// PrintEpoch. It is guarded by clockMu.
+var (
+	clockMu sync.RWMutex
+	nowFunc = time.Now
+)
+
+// SetClock replaces the function returning the current time that is used by
+// PrintDate, PrintTimestamp and PrintEpoch, so that tests can record a fixed
+// date. A nil function restores time.Now.
+func SetClock(fn func() time.Time) {
+	if fn == nil {
+		fn = time.Now
+	}
+	clockMu.Lock()
+	nowFunc = fn
+	clockMu.Unlock()
+}
+
+// now returns the current time according to the registered clock.
+func now() time.Time {
+	clockMu.RLock()
+	fn := nowFunc
+	clockMu.RUnlock()
+	return fn()
+}
+
 // PrintDate returns the Unix formatted Date (UTC) at which an error occured.
 func PrintDate() (fieldName string, date interface{}) {
-	return "date", time.Now().UTC().Format(time.UnixDate)
+	return "date", now().UTC().Format(time.UnixDate)
+}
+
+// PrintTimestamp returns the RFC 3339 formatted date (UTC), with nanoseconds,
+// at which an error occured. Unlike the output of PrintDate, it keeps the
+// sub-second precision and sorts in chronological order.
+func PrintTimestamp() (fieldName string, date interface{}) {
+	return "date", now().UTC().Format(time.RFC3339Nano)
+}
+
+// PrintEpoch returns the date at which an error occured as a number of
+// milliseconds elapsed since the Unix epoch. Milliseconds are used so that the
+// value is not rounded when it is decoded as a floating point number, e.g. by
+// the JSONCodec.
+func PrintEpoch() (fieldName string, date interface{}) {
+	return "date", now().UnixMilli()
+}
+
+// GetDate returns the date stored under the "date" key, as returned by
+// GetInfo, whichever of PrintDate, PrintTimestamp or PrintEpoch recorded it.
+// The boolean is false if there is no date or if it cannot be parsed.
+func (e *Error) GetDate() (time.Time, bool) {
+	v, ok := e.GetInfo("date")
+	if !ok {
+		return time.Time{}, false
+	}
+	return toTime(v)
+}
+
+// toTime converts a date recorded by PrintDate, PrintTimestamp or PrintEpoch,
+// possibly after a round trip through a codec, to a time.Time.
+func toTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, time.UnixDate} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	ms, ok := toInt(v)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms).UTC(), true
 }
 
 // PrintLine returns the line number on which the error occured.
+// The frames of this package are skipped, so that the line of the call to New
+// is reported when PrintLine is used by a constructor.
 func PrintLine() (fieldName string, line interface{}) {
-	_, _, line, _ = runtime.Caller(1)
-	return "line", line
+	return "line", caller(0).Line
 }
 
-// PrintFile returns the name of the package file in which the error occured.
+// PrintFile returns the name of the file in which the error occured.
+// The frames of this package are skipped, so that the file of the call to New
+// is reported when PrintFile is used by a constructor.
 func PrintFile() (fieldName string, file interface{}) {
-	_, file, _, _ = runtime.Caller(0)
-	return "file", file
+	return "file", caller(0).File
 }
 
 // PrintFunc returns the name of the function in which the error occured.
+// The frames of this package are skipped, so that the function calling New is
+// reported when PrintFunc is used by a constructor.
 func PrintFunc() (fieldname string, fn interface{}) {
-	pc, _, _, _ := runtime.Caller(1)
-	f := runtime.FuncForPC(pc)
-	fn = f.Name()
-	return "fn", fn
+	return "fn", caller(0).Func
 }
 
-// PrintTrace returns the name of the function in which the error occured.
+// PrintLineAt returns an information function similar to PrintLine that skips
+// the given number of additional frames. It allows to report the right line
+// when New is called through wrapping functions.
+func PrintLineAt(skip int) func() (fieldName string, line interface{}) {
+	return func() (string, interface{}) {
+		return "line", caller(skip).Line
+	}
+}
+
+// PrintFileAt returns an information function similar to PrintFile that skips
+// the given number of additional frames.
+func PrintFileAt(skip int) func() (fieldName string, file interface{}) {
+	return func() (string, interface{}) {
+		return "file", caller(skip).File
+	}
+}
+
+// PrintFuncAt returns an information function similar to PrintFunc that skips
+// the given number of additional frames.
+func PrintFuncAt(skip int) func() (fieldname string, fn interface{}) {
+	return func() (string, interface{}) {
+		return "fn", caller(skip).Func
+	}
+}
+
+// pkgPrefix is the prefix of the name of the functions of this package.
+var pkgPrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	i := strings.LastIndex(name, "/") + 1
+	return name[:i+strings.Index(name[i:], ".")+1]
+}()
+
+// caller returns the first frame of the stack that does not belong to this
+// package, or one of its callers if skip is greater than zero.
+func caller(skip int) Frame {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	outside := false
+	for {
+		f, more := frames.Next()
+		if outside || !strings.HasPrefix(f.Function, pkgPrefix) {
+			outside = true
+			if skip == 0 {
+				return Frame{f.Function, f.File, f.Line}
+			}
+			skip--
+		}
+		if !more {
+			return Frame{}
+		}
+	}
+}
+
+// PrintTrace returns the stack trace of the goroutine in which the error
+// occured, as a list of frames.
 func PrintTrace() (fieldname string, funcs interface{}) {
-	/*pc := make([]uintptr, 20)
+	return "trace", callers()
+}
 
-	result := make(map[string]struct {
-		File string `json:"file"`
-		Line int    `json:"line"`
-	}, runtime.Callers(0, pc))
+// Frame describes a function call of a stack trace.
+type Frame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
 
-	for _, counter := range pc {
-		f := runtime.FuncForPC(counter)
-		if f != nil {
-			file, line := f.FileLine(0)
-			result[f.Name()] = struct {
-				File string `json:"file"`
-				Line int    `json:"line"`
-			}{file, line}
+// callers returns the frames of the current goroutine stack, starting from the
+// first frame that does not belong to this package.
+func callers() []Frame {
+	pc := make([]uintptr, 32)
+	for {
+		n := runtime.Callers(2, pc)
+		if n < len(pc) {
+			pc = pc[:n]
+			break
+		}
+		pc = make([]uintptr, 2*len(pc))
+	}
+	result := make([]Frame, 0, len(pc))
+	frames := runtime.CallersFrames(pc)
+	for {
+		f, more := frames.Next()
+		if len(result) > 0 || !strings.HasPrefix(f.Function, pkgPrefix) {
+			result = append(result, Frame{f.Function, f.File, f.Line})
+		}
+		if !more {
+			break
 		}
 	}
-	return "trace", result
-	*/
+	return result
+}
+
+// WithStack records the stack trace of the current goroutine in the error.
+// Unlike the DEBUG flag, it allows to capture the trace of selected errors only,
+// at the point where WithStack is called.
+func (e *Error) WithStack() *Error {
+	e.ErrorStack = callers()
+	return e
+}
+
+// Stack returns the stack trace recorded by WithStack, if any.
+func (e *Error) Stack() []Frame {
+	if e == nil {
+		return nil
+	}
+	return e.ErrorStack
+}
+
+// PrintTraceAll returns the stack traces of all the running goroutines.
+// In a busy process, the output may be large and contain data unrelated to the
+// error.
+func PrintTraceAll() (fieldname string, funcs interface{}) {
+	return "trace", string(captureStack(true))
+}
+
+// captureStack returns the stack trace of the current goroutine, or of all
+// goroutines if all is true. The buffer is grown until it is large enough for
+// the traces not to be truncated.
+func captureStack(all bool) []byte {
 	buf := make([]byte, 1024)
-	runtime.Stack(buf, true)
-	return "trace", fmt.Sprint(string(buf))
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
 }
 
 // List  defines a datatype holding a list of error values.
+// Its methods are safe for concurrent use, which allows to collect the errors
+// of several goroutines. Values should not be accessed directly while the list
+// is in use by several goroutines.
+// The zero value is an empty list ready to use.
 type List struct {
 	Values []error
+
+	// HeaderFormat is the format of the first line of PlainError, given the
+	// number of errors, e.g. "%d errors occurred:". If it is empty, that
+	// format is used, or "1 error occurred:" for a single error.
+	HeaderFormat string
+
+	mu      sync.Mutex
+	max     int
+	dropped int
 }
 
-// NewList returns a new, emptyn container for a list of errors.
-func NewList() *List {
+// NewList returns a new container for a list of errors, holding the given
+// errors, if any. Nil errors are skipped.
+func NewList(errs ...error) *List {
 	l := new(List)
-	l.Values = make([]error, 0)
+	l.Values = make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if e, ok := err.(*Error); ok && e == nil {
+			continue
+		}
+		l.Values = append(l.Values, err)
+	}
+	return l
+}
+
+// NewBoundedList returns a new, empty container for a list of at most max
+// errors. Once the list is full, the oldest errors are dropped to make room for
+// the newest ones.
+func NewBoundedList(max int) *List {
+	l := NewList()
+	l.max = max
 	return l
 }
 
 // Add allows to append an error value to an error list.
 func (l *List) Add(e ...error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.add(e...)
+}
+
+func (l *List) add(e ...error) {
 	if l.Values == nil {
 		l.Values = make([]error, 0)
 	}
 	l.Values = append(l.Values, e...)
+	if l.max > 0 && len(l.Values) > l.max {
+		n := len(l.Values) - l.max
+		copy(l.Values, l.Values[n:])
+		for i := l.max; i < len(l.Values); i++ {
+			l.Values[i] = nil
+		}
+		l.Values = l.Values[:l.max]
+		l.dropped += n
+	}
 }
 
-func (l *List) Error() string {
-	var s string
+// values returns a copy of the error values held by the list.
+func (l *List) values() []error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]error(nil), l.Values...)
+}
+
+// Len returns the number of errors held by the list.
+func (l *List) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.Values)
+}
+
+// Dropped returns the number of errors that were dropped because a bounded list
+// was full.
+func (l *List) Dropped() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// AddUnique appends the error values that are not already in the list. Two
+// errors are considered identical if their Error methods return the same
+// string. Nil values are skipped.
+func (l *List) AddUnique(e ...error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addUnique(e...)
+}
+
+func (l *List) addUnique(e ...error) {
+	seen := make(map[string]bool, len(l.Values))
 	for _, v := range l.Values {
-		s = s + v.Error() + "\n"
+		if v != nil {
+			seen[v.Error()] = true
+		}
 	}
-	return s
+	for _, v := range e {
+		if v == nil || seen[v.Error()] {
+			continue
+		}
+		seen[v.Error()] = true
+		l.add(v)
+	}
+}
+
+// Dedup removes the duplicated error values from the list, as well as the nil
+// ones, keeping the first occurrence of each error.
+func (l *List) Dedup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	values := l.Values
+	l.Values = make([]error, 0, len(values))
+	l.addUnique(values...)
+}
+
+// Error returns the JSON serialization of the list, i.e. an array of the
+// serialized errors it holds.
+func (l *List) Error() string {
+	res, err := toJSON(l)
+	if err != nil {
+		return err.Error()
+	}
+	return string(res)
+}
+
+// PlainError returns a header stating the number of errors held by the list,
+// formatted according to HeaderFormat, followed by their messages, one per
+// line. Nil values are skipped. An empty list returns an empty string.
+func (l *List) PlainError() string {
+	var msgs []string
+	for _, v := range l.values() {
+		if v == nil {
+			continue
+		}
+		msgs = append(msgs, v.Error())
+	}
+	if len(msgs) == 0 {
+		return ""
+	}
+	l.mu.Lock()
+	format := l.HeaderFormat
+	l.mu.Unlock()
+	var header string
+	switch {
+	case format != "":
+		header = fmt.Sprintf(format, len(msgs))
+	case len(msgs) == 1:
+		header = "1 error occurred:"
+	default:
+		header = fmt.Sprintf("%d errors occurred:", len(msgs))
+	}
+	return header + "\n" + strings.Join(msgs, "\n") + "\n"
+}
+
+// MarshalJSON encodes the list as a JSON array. Values that are not of type
+// Error nor List are encoded as an Error whose cause is their message.
+// Nil values are skipped.
+func (l *List) MarshalJSON() ([]byte, error) {
+	lv := l.values()
+	values := make([]interface{}, 0, len(lv))
+	for _, v := range lv {
+		switch v := v.(type) {
+		case nil:
+		case *List:
+			if v != nil {
+				values = append(values, v)
+			}
+		case *Error:
+			if v != nil {
+				c := v.Clone()
+				c.applyRedactor()
+				values = append(values, c)
+			}
+		default:
+			values = append(values, &Error{ErrorCause: v.Error()})
+		}
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON decodes a JSON array of errors into the list. Nested arrays are
+// decoded as nested lists.
+func (l *List) UnmarshalJSON(b []byte) error {
+	var values []json.RawMessage
+	if err := json.Unmarshal(b, &values); err != nil {
+		return err
+	}
+	errs := make([]error, 0, len(values))
+	for _, v := range values {
+		if len(v) > 0 && v[0] == '[' {
+			nl := NewList()
+			if err := nl.UnmarshalJSON(v); err != nil {
+				return err
+			}
+			errs = append(errs, nl)
+			continue
+		}
+		var e Error
+		if err := json.Unmarshal(v, &e); err != nil {
+			return err
+		}
+		e.codec = JSONCodec
+		errs = append(errs, &e)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Values = make([]error, 0, len(errs))
+	l.add(errs...)
+	return nil
 }
 
 func (l *List) Nil() bool {
-	return len(l.Values) == 0
+	return l.Len() == 0
+}
+
+// ErrorOrNil returns nil if the list is empty, and the list otherwise, so that
+// a function collecting errors can end with:
+//
+//	return l.ErrorOrNil()
+//
+// It avoids returning a non-nil error interface holding an empty list.
+func (l *List) ErrorOrNil() error {
+	if l == nil || l.Nil() {
+		return nil
+	}
+	return l
+}
+
+// Unwrap returns the errors held by the list so that the standard library
+// errors.Is and errors.As functions can traverse every one of them.
+func (l *List) Unwrap() []error {
+	return l.values()
+}
+
+// Flatten returns the errors held by the list as a flat slice, in order.
+// The values of nested lists are inlined, and every Error is replaced by the
+// errors of its chain, from the outermost to the innermost, each as a copy
+// that does not wrap any other error. The branches of an Error, merged with
+// Merge or wrapped by WrapsAll, are flattened likewise and follow it.
+// Nil values are skipped.
+func (l *List) Flatten() []error {
+	return l.flatten(nil, make(map[*List]bool))
+}
+
+func (l *List) flatten(res []error, visited map[*List]bool) []error {
+	if l == nil || visited[l] {
+		return res
+	}
+	visited[l] = true
+	for _, v := range l.values() {
+		switch v := v.(type) {
+		case nil:
+		case *List:
+			res = v.flatten(res, visited)
+		case *Error:
+			res = v.flatten(res, make(map[*Error]bool))
+		default:
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// flatten appends the errors of the chain of e to res, each followed by its
+// branches, recursively. The Errors already visited are skipped.
+func (e *Error) flatten(res []error, visited map[*Error]bool) []error {
+	e.Walk(func(err *Error) bool {
+		if visited[err] {
+			return false
+		}
+		visited[err] = true
+		c := err.copyLink()
+		c.underlyingStd = nil
+		res = append(res, c)
+		if err.Underlying == nil && err.underlyingStd != nil {
+			res = append(res, err.underlyingStd)
+		}
+		for _, m := range err.ErrorCauses {
+			res = m.flatten(res, visited)
+		}
+		res = append(res, err.causesStd...)
+		return true
+	})
+	return res
+}
+
+// Filter returns every Error of the list that has the given code. Values that
+// are not of type Error are skipped.
+func (l *List) Filter(code int) []*Error {
+	var res []*Error
+	for _, v := range l.values() {
+		if e := As(v); e.HasCode(code) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// First returns the first Error of the list that has the given code, or nil.
+func (l *List) First(code int) *Error {
+	for _, v := range l.values() {
+		if e := As(v); e.HasCode(code) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Any reports whether pred holds for at least one value of the list.
+// It returns false for an empty list.
+func (l *List) Any(pred func(error) bool) bool {
+	for _, v := range l.values() {
+		if pred(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// All reports whether pred holds for every value of the list.
+// It returns true for an empty list.
+func (l *List) All(pred func(error) bool) bool {
+	for _, v := range l.values() {
+		if !pred(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupByCode buckets the Errors of the list by code, in order. Errors without
+// a code are grouped under the empty string. Values that are not of type Error
+// are skipped.
+func (l *List) GroupByCode() map[string][]*Error {
+	groups := make(map[string][]*Error)
+	for _, v := range l.values() {
+		if e := As(v); e != nil {
+			groups[e.ErrorCode] = append(groups[e.ErrorCode], e)
+		}
+	}
+	return groups
+}
+
+// Join folds a set of errors into a single Error. The message of each joined
+// error is recorded under the "errors" info key and the cause is made of
+// these messages separated by newlines. The joined errors are held as the
+// branches of the new error, as with WrapsAll, so that the standard library
+// errors.Is and errors.As functions find them, as they do with errors.Join.
+// Nil errors are skipped. If every error is nil, Join returns nil.
+func Join(errs ...error) *Error {
+	msgs := make([]string, 0, len(errs))
+	e := &Error{codec: JSONCodec}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if ee, ok := err.(*Error); ok {
+			if ee == nil {
+				continue
+			}
+			msgs = append(msgs, ee.ErrorCause)
+			e.ErrorCauses = append(e.ErrorCauses, ee)
+			continue
+		}
+		msgs = append(msgs, err.Error())
+		e.causesStd = append(e.causesStd, err)
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	e.ErrorCause = strings.Join(msgs, "\n")
+	e.AddInfo("errors", msgs)
+	notifyCreate(e)
+	return e
 }
 
 // NOTE While this package defines an error type, the header is entirely customizable.
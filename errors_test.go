@@ -1,8 +1,17 @@
 package errors_test
 
 import (
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/atdiar/errors"
 )
@@ -17,15 +26,1558 @@ func Example() {
 
 	vIface = v
 
-	fmt.Print(vIface)
+	fmt.Print(vIface.Error())
 	// Output:
 	//{
 	//  "ErrorInfo": {
 	//   "date": "Tue Nov 10 23:00:00 UTC 2009",
-	//   "file": "/home/atd/go/src/github.com/atdiar/errors/errors.go",
+	//   "file": "/home/atd/go/src/github.com/atdiar/errors/errors_test.go",
 	//   "fn": "github.com/atdiar/errors_test.Example",
-	//   "line": 13
+	//   "line": 22
 	//  },
 	//  "ErrorCause": "Something happened."
 	//}
 }
+
+func TestUnwrap(t *testing.T) {
+	root := errors.New("root")
+	middle := errors.New("middle").Wraps(root)
+	chain := errors.New("top").Wraps(middle)
+
+	var target *errors.Error
+	if !stderrors.As(chain, &target) {
+		t.Fatal("expected errors.As to find an *Error in the chain")
+	}
+
+	innermost := chain
+	for next := errors.As(innermost.Source()); next != nil; next = errors.As(innermost.Source()) {
+		innermost = next
+	}
+	if innermost != root {
+		t.Fatalf("expected the innermost error to be the root, got %v", innermost)
+	}
+	if !stderrors.Is(chain, root) {
+		t.Fatal("expected errors.Is to find the root error in the chain")
+	}
+
+	if err := root.Source(); err != nil {
+		t.Fatalf("expected a nil error interface when there is no cause, got %#v", err)
+	}
+	if errs := root.Unwrap(); errs != nil {
+		t.Fatalf("expected no error to be unwrapped when there is no cause, got %v", errs)
+	}
+	if errs := chain.Unwrap(); len(errs) != 1 || errs[0] != middle {
+		t.Fatalf("expected the underlying error to be unwrapped, got %v", errs)
+	}
+}
+
+func TestIs(t *testing.T) {
+	sentinel := errors.New("not found").Code(404)
+	wrapped := errors.New("loading user").Wraps(errors.New("query failed").Code(404))
+
+	if !stderrors.Is(wrapped, sentinel) {
+		t.Fatal("expected errors.Is to match the sentinel code in the chain")
+	}
+	if stderrors.Is(wrapped, errors.New("forbidden").Code(403)) {
+		t.Fatal("expected errors.Is not to match a different code")
+	}
+	if !stderrors.Is(wrapped, errors.New("query failed")) {
+		t.Fatal("expected errors.Is to match on the cause when the target has no code")
+	}
+	if !wrapped.Underlying.HasCode(404) || wrapped.HasCode(404) {
+		t.Fatal("expected HasCode to only compare the code of the receiver")
+	}
+}
+
+func TestListUnwrap(t *testing.T) {
+	target := errors.New("timeout").Code(504)
+	l := errors.NewList()
+	l.Add(fmt.Errorf("plain"), target)
+
+	if !stderrors.Is(l, target) {
+		t.Fatal("expected errors.Is to find an error held by the list")
+	}
+	var e *errors.Error
+	if !stderrors.As(l, &e) || e != target {
+		t.Fatal("expected errors.As to find the *Error held by the list")
+	}
+}
+
+func TestJoin(t *testing.T) {
+	if errors.Join() != nil || errors.Join(nil, nil) != nil {
+		t.Fatal("expected joining only nil errors to return nil")
+	}
+
+	var nilErr *errors.Error
+	j := errors.Join(errors.New("first"), nil, nilErr, fmt.Errorf("second"))
+	if j == nil {
+		t.Fatal("expected a non-nil joined error")
+	}
+	if j.ErrorCause != "first\nsecond" {
+		t.Fatalf("unexpected cause: %q", j.ErrorCause)
+	}
+	msgs, ok := j.ErrorInfo["errors"].([]string)
+	if !ok || len(msgs) != 2 || msgs[0] != "first" || msgs[1] != "second" {
+		t.Fatalf("unexpected joined messages: %v", j.ErrorInfo["errors"])
+	}
+
+	first, tmp := errors.New("first").Code(404), &temporaryError{"try again"}
+	j = errors.Join(first, io.EOF, tmp)
+	if !stderrors.Is(j, first) || !stderrors.Is(j, io.EOF) {
+		t.Fatal("expected errors.Is to find the joined errors")
+	}
+	var te *temporaryError
+	if !stderrors.As(j, &te) || te != tmp {
+		t.Fatal("expected errors.As to find a joined error")
+	}
+	if len(j.Branches()) != 3 {
+		t.Fatalf("expected the joined errors to be held as branches, got %v", j.Branches())
+	}
+}
+
+func recurse(depth int) string {
+	if depth == 0 {
+		_, trace := errors.PrintTraceAll()
+		return trace.(string)
+	}
+	return recurse(depth - 1)
+}
+
+func TestPrintTraceAllNotTruncated(t *testing.T) {
+	trace := recurse(200)
+	if len(trace) <= 1024 {
+		t.Fatalf("expected a trace longer than the initial buffer, got %d bytes", len(trace))
+	}
+	if !strings.Contains(trace, "TestPrintTraceAllNotTruncated") {
+		t.Fatal("expected the outermost frames of a deep stack to be preserved")
+	}
+}
+
+func TestPrintTrace(t *testing.T) {
+	_, trace := errors.PrintTrace()
+	frames, ok := trace.([]errors.Frame)
+	if !ok || len(frames) == 0 {
+		t.Fatalf("expected a list of frames, got %#v", trace)
+	}
+	if frames[0].Func != "github.com/atdiar/errors_test.TestPrintTrace" {
+		t.Fatalf("unexpected function name for the first frame: %s", frames[0].Func)
+	}
+	if !strings.HasSuffix(frames[0].File, "errors_test.go") || frames[0].Line == 0 {
+		t.Fatalf("unexpected location for the first frame: %s:%d", frames[0].File, frames[0].Line)
+	}
+}
+
+func TestPrintTraceAll(t *testing.T) {
+	done := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		close(started)
+		<-done
+	}()
+	<-started
+	defer close(done)
+
+	_, traces := errors.PrintTraceAll()
+	if n := strings.Count("\n"+traces.(string), "\ngoroutine "); n < 2 {
+		t.Fatalf("expected the traces of every goroutine, got %d", n)
+	}
+}
+
+func TestGetCode(t *testing.T) {
+	e := errors.New("not found")
+	if _, ok := e.GetCode(); ok {
+		t.Fatal("expected no code to be set")
+	}
+	c, ok := e.Code(404).GetCode()
+	if !ok || c != 404 {
+		t.Fatalf("expected code 404, got %d (%v)", c, ok)
+	}
+	if _, ok := e.ErrorInfo["Code"]; ok {
+		t.Fatal("expected the code not to be duplicated in the error info")
+	}
+}
+
+func TestSymbolicCode(t *testing.T) {
+	inner := errors.New("deadline exceeded").CodeString("E_TIMEOUT")
+	chain := errors.New("fetching profile").Code(500).Wraps(inner)
+
+	if !chain.HasCode(500) || chain.IsCode("E_TIMEOUT") {
+		t.Fatal("expected the outer error to only have the numeric code")
+	}
+	if !inner.IsCode("E_TIMEOUT") || inner.HasCode(500) {
+		t.Fatal("expected the inner error to only have the symbolic code")
+	}
+	if _, ok := inner.GetCode(); ok {
+		t.Fatal("expected a symbolic code not to be returned as a numeric one")
+	}
+	if !stderrors.Is(chain, errors.New("timeout").CodeString("E_TIMEOUT")) {
+		t.Fatal("expected errors.Is to match the symbolic code in the chain")
+	}
+	if !stderrors.Is(chain, errors.New("internal").Code(500)) {
+		t.Fatal("expected errors.Is to match the numeric code in the chain")
+	}
+}
+
+func TestAddInfoConcurrent(t *testing.T) {
+	e := errors.New("shared")
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e.AddInfo("worker"+strconv.Itoa(i), i)
+			_ = e.Error()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 50; i++ {
+		if v := e.ErrorInfo["worker"+strconv.Itoa(i)]; v != i {
+			t.Fatalf("expected worker%d to be recorded, got %v", i, v)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	src := errors.New("template").Code(400).AddInfo("component", "api")
+	src = src.Wraps(errors.New("cause").AddInfo("query", "select"))
+
+	c := src.Clone()
+	c.AddInfo("component", "worker").AddInfo("extra", true)
+	c.Underlying.AddInfo("query", "update")
+
+	if src.ErrorInfo["component"] != "api" || src.ErrorInfo["extra"] != nil {
+		t.Fatalf("expected the source info to be untouched, got %v", src.ErrorInfo)
+	}
+	if src.Underlying.ErrorInfo["query"] != "select" {
+		t.Fatal("expected the source chain to be untouched")
+	}
+	if c.ErrorCode != src.ErrorCode || c.ErrorCause != src.ErrorCause || c.Underlying == src.Underlying {
+		t.Fatal("expected the clone to be a deep copy of the source")
+	}
+	if c.Error() == src.Error() {
+		t.Fatal("expected the clone to serialize differently once modified")
+	}
+}
+
+func TestWraps(t *testing.T) {
+	template := errors.New("request failed").Code(502)
+	first := errors.New("connection refused")
+	second := errors.New("connection reset")
+
+	a := template.Wraps(first)
+	b := template.Wraps(second)
+
+	if template.Underlying != nil {
+		t.Fatal("expected the template not to be modified by Wraps")
+	}
+	if a == template || b == template || a == b {
+		t.Fatal("expected Wraps to return fresh errors")
+	}
+	if a.Underlying != first || b.Underlying != second {
+		t.Fatal("expected each wrapped error to keep its own cause")
+	}
+	if !a.HasCode(502) || a.ErrorCause != template.ErrorCause {
+		t.Fatal("expected the wrapping error to be a copy of the template")
+	}
+}
+
+func TestWrapsStandardError(t *testing.T) {
+	sentinel := stderrors.New("no rows")
+	cause := fmt.Errorf("querying users: %w", sentinel)
+	e := errors.New("loading profile").Wraps(cause)
+
+	if e.Underlying != nil {
+		t.Fatal("expected a standard error not to be decoded into an Error")
+	}
+	if e.Source() != cause {
+		t.Fatal("expected Source to return the original error")
+	}
+	if !stderrors.Is(e, sentinel) {
+		t.Fatal("expected errors.Is to match the original error")
+	}
+}
+
+func TestCompactJSONCodec(t *testing.T) {
+	newCompact := errors.Constructor(errors.CompactJSONCodec, errors.WithInfoFuncs(errors.PrintFunc))
+	e := newCompact("something happened").AddInfo("component", "api").Wraps(newCompact("root"))
+
+	s := e.Error()
+	if strings.Contains(s, "\n") {
+		t.Fatalf("expected a single line, got %q", s)
+	}
+	d := errors.CompactJSONCodec.Decode([]byte(s))
+	if d.ErrorCause != e.ErrorCause || d.ErrorInfo["component"] != "api" {
+		t.Fatalf("unexpected decoded error: %#v", d)
+	}
+	if s := d.Error(); strings.Contains(s, "\n") {
+		t.Fatalf("expected the decoded error to remain on a single line, got %q", s)
+	}
+	if s := d.Underlying.Error(); strings.Contains(s, "\n") {
+		t.Fatalf("expected the decoded underlying error to remain on a single line, got %q", s)
+	}
+	if !strings.Contains(errors.New("indented").Error(), "\n") {
+		t.Fatal("expected the default codec to remain indented")
+	}
+}
+
+func TestFields(t *testing.T) {
+	cause := errors.New("query failed").AddInfo("component", "db").AddInfo("table", "users")
+	e := errors.New("loading profile").Code(500).AddInfo("component", "api").Wraps(cause)
+
+	f := e.Fields()
+	if f["component"] != "api" {
+		t.Fatalf("expected the outer value to win, got %v", f["component"])
+	}
+	if f["table"] != "users" {
+		t.Fatal("expected the inherited fields to be present")
+	}
+	if f["ErrorCode"] != "500" || f["ErrorCause"] != "loading profile" {
+		t.Fatalf("unexpected reserved fields: %v", f)
+	}
+}
+
+func TestRootCause(t *testing.T) {
+	root := errors.New("level 4")
+	e := errors.New("level 1").Wraps(errors.New("level 2").Wraps(errors.New("level 3").Wraps(root)))
+
+	if e.RootCause() != root {
+		t.Fatalf("expected the innermost error, got %v", e.RootCause())
+	}
+	if e.Cause() != root {
+		t.Fatalf("expected the innermost error as a standard error, got %v", e.Cause())
+	}
+	if root.RootCause() != root || root.Cause() != root {
+		t.Fatal("expected an unwrapped error to be its own root cause")
+	}
+
+	var nilErr *errors.Error
+	if nilErr.RootCause() != nil || nilErr.Cause() != nil {
+		t.Fatal("expected a nil error to have no root cause")
+	}
+
+	std := fmt.Errorf("EOF")
+	if errors.New("reading").Wraps(std).Cause() != std {
+		t.Fatal("expected Cause to return the wrapped standard error")
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	t1 := errors.New("timeout 1").Code(504)
+	t2 := errors.New("timeout 2").Code(504)
+	l := errors.NewList()
+	l.Add(fmt.Errorf("504"), errors.New("not found").Code(404), t1, stderrors.New("plain"), t2)
+
+	timeouts := l.Filter(504)
+	if len(timeouts) != 2 || timeouts[0] != t1 || timeouts[1] != t2 {
+		t.Fatalf("unexpected filtered errors: %v", timeouts)
+	}
+	if l.First(504) != t1 {
+		t.Fatal("expected the first timeout")
+	}
+	if l.First(500) != nil || len(l.Filter(500)) != 0 {
+		t.Fatal("expected no match for an absent code")
+	}
+}
+
+func TestListAddUnique(t *testing.T) {
+	e := errors.New("retry failed")
+	l := errors.NewList()
+	l.AddUnique(e, nil, e)
+	l.AddUnique(e)
+	if len(l.Values) != 1 {
+		t.Fatalf("expected a single error, got %d", len(l.Values))
+	}
+
+	l.AddUnique(fmt.Errorf("other"))
+	if len(l.Values) != 2 {
+		t.Fatalf("expected a second distinct error, got %d", len(l.Values))
+	}
+}
+
+func TestListDedup(t *testing.T) {
+	e := errors.New("retry failed")
+	other := fmt.Errorf("other")
+	l := errors.NewList()
+	l.Add(e, e, other, e, fmt.Errorf("other"))
+	l.Dedup()
+	if len(l.Values) != 2 || l.Values[0] != e || l.Values[1] != other {
+		t.Fatalf("unexpected deduplicated list: %v", l.Values)
+	}
+}
+
+func TestListJSON(t *testing.T) {
+	nested := errors.NewList()
+	nested.Add(errors.New("nested"))
+	l := errors.NewList()
+	l.Add(errors.New("not found").Code(404).AddInfo("path", "/users/42"), fmt.Errorf("plain"), nil, nested)
+
+	s := l.Error()
+	if !strings.HasPrefix(s, "[") {
+		t.Fatalf("expected a JSON array, got %s", s)
+	}
+
+	var d errors.List
+	if err := json.Unmarshal([]byte(s), &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Values) != 3 {
+		t.Fatalf("expected 3 decoded errors, got %d", len(d.Values))
+	}
+	e := errors.As(d.Values[0])
+	if e == nil || e.ErrorCause != "not found" || e.ErrorInfo["path"] != "/users/42" {
+		t.Fatalf("unexpected first error: %#v", d.Values[0])
+	}
+	if p := errors.As(d.Values[1]); p == nil || p.ErrorCause != "plain" {
+		t.Fatalf("unexpected second error: %#v", d.Values[1])
+	}
+	n, ok := d.Values[2].(*errors.List)
+	if !ok || len(n.Values) != 1 || errors.As(n.Values[0]).ErrorCause != "nested" {
+		t.Fatalf("unexpected nested list: %#v", d.Values[2])
+	}
+	if d.Error() != s {
+		t.Fatal("expected the decoded list to encode identically")
+	}
+
+	if p := l.PlainError(); strings.Count(p, "\n") < 4 || strings.HasPrefix(p, "[") {
+		t.Fatalf("unexpected plain output: %q", p)
+	}
+}
+
+func TestBoundedList(t *testing.T) {
+	l := errors.NewBoundedList(10)
+	for i := 0; i < 15; i++ {
+		l.Add(errors.New(strconv.Itoa(i)))
+	}
+	if l.Len() != 10 {
+		t.Fatalf("expected the list to be capped to 10 errors, got %d", l.Len())
+	}
+	if l.Dropped() != 5 {
+		t.Fatalf("expected 5 dropped errors, got %d", l.Dropped())
+	}
+	if errors.As(l.Values[0]).ErrorCause != "5" || errors.As(l.Values[9]).ErrorCause != "14" {
+		t.Fatal("expected the newest errors to be kept")
+	}
+
+	u := errors.NewList()
+	for i := 0; i < 15; i++ {
+		u.Add(errors.New(strconv.Itoa(i)))
+	}
+	if u.Len() != 15 || u.Dropped() != 0 {
+		t.Fatal("expected an unbounded list not to drop errors")
+	}
+}
+
+func TestListConcurrent(t *testing.T) {
+	var l errors.List
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l.Add(errors.New(strconv.Itoa(i)))
+			_ = l.Nil()
+			_ = l.Error()
+		}(i)
+	}
+	wg.Wait()
+	if l.Len() != 100 {
+		t.Fatalf("expected 100 errors, got %d", l.Len())
+	}
+}
+
+func TestNewCallerLine(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	e := errors.New("something happened")
+
+	if e.ErrorInfo["line"] != line+1 {
+		t.Fatalf("expected line %d, got %v", line+1, e.ErrorInfo["line"])
+	}
+	if e.ErrorInfo["fn"] != "github.com/atdiar/errors_test.TestNewCallerLine" {
+		t.Fatalf("unexpected function: %v", e.ErrorInfo["fn"])
+	}
+
+	_, _, line, _ = runtime.Caller(0)
+	e = errors.New("wrapper").Wraps(e)
+	if e.ErrorInfo["line"] != line+1 {
+		t.Fatalf("expected line %d, got %v", line+1, e.ErrorInfo["line"])
+	}
+}
+
+func TestNewCallerFile(t *testing.T) {
+	file, ok := errors.New("something happened").ErrorInfo["file"].(string)
+	if !ok || !strings.HasSuffix(file, "errors_test.go") {
+		t.Fatalf("expected the file of the caller, got %v", file)
+	}
+}
+
+var newAppError = errors.Constructor(errors.JSONCodec, errors.WithInfoFuncs(errors.PrintFileAt(1), errors.PrintFuncAt(1), errors.PrintLineAt(1)))
+
+// appError adds an extra frame between the caller and the constructor.
+func appError(message string) *errors.Error {
+	return newAppError("app: " + message)
+}
+
+func TestPrintAt(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	e := appError("something happened")
+
+	if e.ErrorInfo["line"] != line+1 || e.ErrorInfo["file"] != file {
+		t.Fatalf("expected %s:%d, got %v:%v", file, line+1, e.ErrorInfo["file"], e.ErrorInfo["line"])
+	}
+	if e.ErrorInfo["fn"] != "github.com/atdiar/errors_test.TestPrintAt" {
+		t.Fatalf("unexpected function: %v", e.ErrorInfo["fn"])
+	}
+}
+
+func TestWithStack(t *testing.T) {
+	e := errors.New("important").WithStack()
+	stack := e.Stack()
+	if len(stack) == 0 || stack[0].Func != "github.com/atdiar/errors_test.TestWithStack" {
+		t.Fatalf("expected the stack to start at the caller, got %v", stack)
+	}
+	if errors.New("plain").Stack() != nil {
+		t.Fatal("expected no stack by default")
+	}
+
+	for name, codec := range map[string]errors.Codec{
+		"json": errors.JSONCodec,
+		"xml":  errors.XMLCodec,
+		"gob":  errors.GobCodec,
+	} {
+		d := codec.Decode([]byte(errors.Constructor(codec)("important").WithStack().Error()))
+		if s := d.Stack(); len(s) == 0 || s[0].Func != stack[0].Func || s[0].File != stack[0].File {
+			t.Fatalf("%s: expected the stack to survive a round-trip, got %v", name, s)
+		}
+	}
+	if !strings.Contains(fmt.Sprintf("%+v", e), "    stack:\n        github.com/atdiar/errors_test.TestWithStack\n") {
+		t.Fatal("expected the stack to be printed in the verbose format")
+	}
+}
+
+func TestDebug(t *testing.T) {
+	on := errors.New("verbose").Debug(true)
+	off := errors.New("quiet").Debug(false)
+
+	if !strings.Contains(on.Error(), "TRACE") {
+		t.Fatal("expected a stack trace for the error in debug mode")
+	}
+	if strings.Contains(off.Error(), "TRACE") {
+		t.Fatal("expected no stack trace for the error with debug disabled")
+	}
+	if strings.Contains(errors.New("default").Error(), "TRACE") != errors.DEBUG.IsTrue() {
+		t.Fatal("expected the global flag to apply when the error does not override it")
+	}
+
+	block := make(chan struct{})
+	defer close(block)
+	go func() { <-block }()
+	if n := strings.Count(errors.New("verbose").Debug(true).Error(), "\ngoroutine "); n != 1 {
+		t.Fatalf("expected the trace of the current goroutine only, got %d goroutines", n)
+	}
+}
+
+func createDebugError() *errors.Error {
+	return errors.New("verbose").Debug(true)
+}
+
+func TestDebugTraceCapturedOnce(t *testing.T) {
+	e := createDebugError()
+	s := e.Error()
+	if !strings.Contains(s, "createDebugError") {
+		t.Fatal("expected the trace to reflect the origin of the error")
+	}
+	if e.Error() != s {
+		t.Fatal("expected the stored trace to be rendered on every call")
+	}
+}
+
+func BenchmarkErrorDebug(b *testing.B) {
+	e := errors.New("verbose").Debug(true)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = e.Error()
+	}
+}
+
+// BenchmarkErrorDebugCapture captures the traces on every call to Error, which
+// is what happened before they were stored in the error.
+func BenchmarkErrorDebugCapture(b *testing.B) {
+	e := errors.New("verbose")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = e.Clone().Debug(true).Error()
+	}
+}
+
+func TestErrorCache(t *testing.T) {
+	e := errors.Constructor(errors.JSONCodec)("simple")
+	s := e.Error()
+	if e.Error() != s {
+		t.Fatal("expected the cached serialization to be identical")
+	}
+
+	e.ErrorCause = "changed"
+	if !strings.Contains(e.Error(), "changed") {
+		t.Fatal("expected a change of cause to invalidate the cache")
+	}
+	e.AddInfo("key", "value")
+	if !strings.Contains(e.Error(), "value") {
+		t.Fatal("expected added information to be serialized")
+	}
+}
+
+func BenchmarkErrorSimple(b *testing.B) {
+	e := errors.Constructor(errors.JSONCodec)("simple")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = e.Error()
+	}
+}
+
+func BenchmarkErrorWithInfo(b *testing.B) {
+	e := errors.Constructor(errors.JSONCodec)("decorated").AddInfo("key", "value")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = e.Error()
+	}
+}
+
+func BenchmarkErrorChain(b *testing.B) {
+	newError := errors.Constructor(errors.JSONCodec)
+	e := newError("root").AddInfo("key", "value")
+	for i := 0; i < 5; i++ {
+		e = newError("level "+strconv.Itoa(i)).AddInfo("key", "value").Wraps(e)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = e.Error()
+	}
+}
+
+func TestConstructorInfoPanic(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec, errors.WithInfoFuncs(
+		func() (string, interface{}) { return "before", 1 },
+		func() (string, interface{}) { panic("broken extractor") },
+		func() (string, interface{}) { return "after", 2 },
+	))
+	e := newError("still created")
+	if e.ErrorCause != "still created" || e.ErrorInfo["before"] != 1 || e.ErrorInfo["after"] != 2 {
+		t.Fatalf("unexpected error: %#v", e)
+	}
+	if e.ErrorInfo["info_error"] != "broken extractor" {
+		t.Fatalf("expected the failure to be recorded, got %v", e.ErrorInfo["info_error"])
+	}
+}
+
+func TestErrorJSONEmbedding(t *testing.T) {
+	type response struct {
+		Status string
+		Err    *errors.Error
+	}
+	e := errors.Constructor(errors.JSONCodec)("not found").AddInfo("path", "/users/42").
+		Wraps(errors.Constructor(errors.JSONCodec)("no rows"))
+
+	b, err := json.Marshal(response{"failed", e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Status":"failed","Err":{"ErrorInfo":{"path":"/users/42"},"ErrorCause":"not found","ErrorSource":{"ErrorCause":"no rows"}}}`
+	if string(b) != want {
+		t.Fatalf("unexpected encoding:\n%s\nwant:\n%s", b, want)
+	}
+
+	var r response
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Err.ErrorCause != "not found" || r.Err.ErrorInfo["path"] != "/users/42" || r.Err.Underlying.ErrorCause != "no rows" {
+		t.Fatalf("unexpected decoded error: %#v", r.Err)
+	}
+	if r.Err.Error() != e.Error() {
+		t.Fatal("expected the decoded error to serialize like the original one")
+	}
+}
+
+func TestCodeRoundTrip(t *testing.T) {
+	for name, codec := range map[string]errors.Codec{
+		"json":         errors.JSONCodec,
+		"compact json": errors.CompactJSONCodec,
+	} {
+		e := errors.Constructor(codec)("not found").Code(404).Wraps(errors.Constructor(codec)("timeout").CodeString("E_TIMEOUT"))
+		d := codec.Decode([]byte(e.Error()))
+		if !d.HasCode(404) || !d.Underlying.IsCode("E_TIMEOUT") {
+			t.Fatalf("%s: expected the codes to survive a round-trip, got %q and %q", name, d.ErrorCode, d.Underlying.ErrorCode)
+		}
+		if !stderrors.Is(d, errors.New("sentinel").CodeString("E_TIMEOUT")) {
+			t.Fatalf("%s: expected errors.Is to match the decoded code", name)
+		}
+	}
+}
+
+func TestChainRoundTrip(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	e := newError("level 1").Code(500).AddInfo("component", "api").Wraps(
+		newError("level 2").CodeString("E_DB").Severity(errors.SeverityWarn).AddInfo("table", "users").Wraps(
+			newError("level 3").Code(404).AddInfo("id", "42").Wraps(fmt.Errorf("no rows"))))
+
+	d := errors.JSONCodec.Decode([]byte(e.Error()))
+	levels := []struct {
+		cause, code, key, value string
+	}{
+		{"level 1", "500", "component", "api"},
+		{"level 2", "E_DB", "table", "users"},
+		{"level 3", "404", "id", "42"},
+	}
+	err := d
+	for _, l := range levels {
+		if err == nil {
+			t.Fatalf("missing level %q", l.cause)
+		}
+		if err.ErrorCause != l.cause || err.ErrorCode != l.code || err.ErrorInfo[l.key] != l.value {
+			t.Fatalf("unexpected decoded level: %#v", err)
+		}
+		err = err.Underlying
+	}
+	if err == nil || err.ErrorCause != "no rows" || err.Underlying != nil {
+		t.Fatalf("expected the wrapped standard error to be decoded as the last level, got %#v", err)
+	}
+	if d.Underlying.GetSeverity() != errors.SeverityWarn {
+		t.Fatal("expected the severity to survive the round-trip")
+	}
+	if d.Error() != e.Error() {
+		t.Fatal("expected the decoded chain to serialize like the original one")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	e := errors.New("level 1").Code(500).Wraps(errors.New("level 2").Code(404).Wraps(errors.New("level 3").Code(400)))
+
+	var codes []string
+	e.Walk(func(err *errors.Error) bool {
+		codes = append(codes, err.ErrorCode)
+		return true
+	})
+	if strings.Join(codes, ",") != "500,404,400" {
+		t.Fatalf("expected every level from outer to inner, got %v", codes)
+	}
+
+	var visited int
+	var found *errors.Error
+	e.Walk(func(err *errors.Error) bool {
+		visited++
+		if err.HasCode(404) {
+			found = err
+			return false
+		}
+		return true
+	})
+	if visited != 2 || found != e.Underlying {
+		t.Fatalf("expected the walk to stop at the second level, visited %d", visited)
+	}
+}
+
+func TestDepth(t *testing.T) {
+	var nilErr *errors.Error
+	if d := nilErr.Depth(); d != 0 {
+		t.Fatalf("expected a nil error to have a depth of 0, got %d", d)
+	}
+	root := errors.New("root")
+	if d := root.Depth(); d != 1 {
+		t.Fatalf("expected an unwrapped error to have a depth of 1, got %d", d)
+	}
+	if d := errors.New("level 1").Wraps(errors.New("level 2").Wraps(root)).Depth(); d != 3 {
+		t.Fatalf("expected a depth of 3, got %d", d)
+	}
+
+	self := errors.New("self")
+	self.Underlying = self
+	if d := self.Depth(); d != 1 {
+		t.Fatalf("expected a self-referencing error to have a depth of 1, got %d", d)
+	}
+}
+
+func TestCyclicChain(t *testing.T) {
+	a := errors.New("a")
+	b := errors.New("b")
+	a.Underlying = b
+	b.Underlying = a
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = a.Error()
+		_ = fmt.Sprintf("%v %+v", a, a)
+		_ = a.Fields()
+		_ = a.RootCause()
+		_ = a.Retryable()
+		_, _ = errors.GobCodec.Encode(a)
+		_, _ = errors.XMLCodec.Encode(a)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the walk over a cyclic chain to terminate")
+	}
+
+	if d := a.Depth(); d != 2 {
+		t.Fatalf("expected a depth of 2, got %d", d)
+	}
+	if c := a.Clone(); c.Underlying == nil || c.Underlying.Underlying != nil {
+		t.Fatal("expected the clone to stop before the first repeated error")
+	}
+	if w := a.Wraps(a); w == a || w.Underlying != b || w.ErrorCause != "a" {
+		t.Fatal("expected an error to refuse to wrap itself and to return a copy")
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	inner := errors.New("inner").AddInfo("user", "bob").AddInfo("attempts", 3).AddInfo("cached", true)
+	e := errors.New("outer").AddInfo("user", "alice").Wraps(inner)
+
+	if v, ok := e.GetInfo("user"); !ok || v != "alice" {
+		t.Fatalf("expected the outermost value to win, got %v", v)
+	}
+	if _, ok := e.GetInfo("missing"); ok {
+		t.Fatal("expected an absent key not to be found")
+	}
+	if s, ok := e.GetString("user"); !ok || s != "alice" {
+		t.Fatalf("expected alice, got %q", s)
+	}
+	if i, ok := e.GetInt("attempts"); !ok || i != 3 {
+		t.Fatalf("expected 3 attempts, got %d", i)
+	}
+	if b, ok := e.GetBool("cached"); !ok || !b {
+		t.Fatal("expected the cached flag to be found in the chain")
+	}
+
+	if _, ok := e.GetString("attempts"); ok {
+		t.Fatal("expected an integer not to be returned as a string")
+	}
+	if _, ok := e.GetInt("user"); ok {
+		t.Fatal("expected a string not to be returned as an integer")
+	}
+	if _, ok := e.GetBool("missing"); ok {
+		t.Fatal("expected an absent key not to be found")
+	}
+
+	decoded := errors.JSONCodec.Decode([]byte(e.Error()))
+	if i, ok := decoded.GetInt("attempts"); !ok || i != 3 {
+		t.Fatalf("expected the decoded number to be converted, got %d", i)
+	}
+}
+
+func TestDeleteInfo(t *testing.T) {
+	e := errors.New("scrubbed").AddInfo("user", "bob").AddInfo("token", "s3cr3t")
+	if !e.HasInfo("token") || !e.HasInfo("user") {
+		t.Fatal("expected the information to be present")
+	}
+	if e.DeleteInfo("token", "missing") != e {
+		t.Fatal("expected DeleteInfo to return the receiver")
+	}
+	if e.HasInfo("token") || e.HasInfo("missing") {
+		t.Fatal("expected the deleted key to be absent")
+	}
+	if !e.HasInfo("user") {
+		t.Fatal("expected the other information to be kept")
+	}
+	if strings.Contains(e.Error(), "s3cr3t") {
+		t.Fatalf("expected the deleted information not to be serialized, got %s", e.Error())
+	}
+
+	empty := errors.New("empty")
+	empty.DeleteInfo("missing")
+	if empty.HasInfo("missing") {
+		t.Fatal("expected an error without information to hold none")
+	}
+
+	var nilErr *errors.Error
+	if nilErr.HasInfo("user") {
+		t.Fatal("expected a nil error to hold no information")
+	}
+	if nilErr.DeleteInfo("user") != nil {
+		t.Fatal("expected DeleteInfo to return a nil receiver as is")
+	}
+}
+
+func TestAddInfoFrom(t *testing.T) {
+	low := errors.New("connection reset").AddInfo("host", "db1").AddInfo("user", "bob")
+
+	e := errors.New("query failed").AddInfo("user", "alice").AddInfoFrom(low)
+	if e.ErrorInfo["host"] != "db1" {
+		t.Fatal("expected the missing information to be copied")
+	}
+	if e.ErrorInfo["user"] != "alice" {
+		t.Fatal("expected the existing information to be kept")
+	}
+	if e.Underlying != nil {
+		t.Fatal("expected the errors not to be linked")
+	}
+
+	o := errors.New("query failed").AddInfo("user", "alice").OverwriteInfoFrom(low)
+	if o.ErrorInfo["host"] != "db1" || o.ErrorInfo["user"] != "bob" {
+		t.Fatalf("expected the information of the other error to win, got %v", o.ErrorInfo)
+	}
+
+	low.AddInfo("host", "db2")
+	if e.ErrorInfo["host"] != "db1" {
+		t.Fatal("expected the information to be copied")
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	root := errors.New("permission denied")
+	e := root.Annotate("open config.json").Annotate("loading config")
+
+	if e.ErrorCause != "loading config" || e.Underlying.Underlying != root {
+		t.Fatal("expected the annotations to wrap the receiver")
+	}
+	if m := e.FullMessage(); m != "loading config: open config.json: permission denied" {
+		t.Fatalf("unexpected full message: %s", m)
+	}
+	if m := fmt.Sprintf("%v", e); m != e.FullMessage() {
+		t.Fatalf("expected %%v to render the full message, got %s", m)
+	}
+	if !strings.Contains(e.Error(), "permission denied") {
+		t.Fatalf("expected the annotated error to be serializable, got %s", e.Error())
+	}
+}
+
+func TestNewf(t *testing.T) {
+	e := errors.Newf("user %q not found (id %d)", "bob", 42)
+	if e.ErrorCause != `user "bob" not found (id 42)` {
+		t.Fatalf("unexpected cause: %s", e.ErrorCause)
+	}
+	if _, ok := e.ErrorInfo["line"]; !ok {
+		t.Fatal("expected the default information to be recorded")
+	}
+
+	std := stderrors.New("connection refused")
+	w := errors.Wrapf(std, "dialing %s", "db1")
+	if w.ErrorCause != "dialing db1" || w.Source() != std {
+		t.Fatal("expected Wrapf to wrap the standard error")
+	}
+	if !stderrors.Is(w, std) {
+		t.Fatal("expected the wrapped error to be found by errors.Is")
+	}
+
+	w = errors.Wrapf(e, "request %d failed", 7)
+	if w.Underlying != e || w.Source() != e {
+		t.Fatal("expected Wrapf to wrap the Error")
+	}
+	if m := w.FullMessage(); m != `request 7 failed: user "bob" not found (id 42)` {
+		t.Fatalf("unexpected full message: %s", m)
+	}
+}
+
+func TestConstructorOptions(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec,
+		errors.WithInfoFuncs(errors.PrintFunc),
+		errors.WithDefaultCode(500),
+		errors.WithSeverity(errors.SeverityFatal),
+		errors.WithStack(),
+	)
+	for _, msg := range []string{"first", "second"} {
+		e := newError(msg)
+		if c, ok := e.GetCode(); !ok || c != 500 {
+			t.Fatalf("expected the default code to be stamped on %q, got %v", msg, e.ErrorCode)
+		}
+		if e.GetSeverity() != errors.SeverityFatal {
+			t.Fatalf("expected the default severity, got %s", e.GetSeverity())
+		}
+		if len(e.Stack()) == 0 || !strings.HasSuffix(e.Stack()[0].Func, "TestConstructorOptions") {
+			t.Fatalf("expected the stack to start at the caller, got %v", e.Stack())
+		}
+		if _, ok := e.ErrorInfo["fn"]; !ok {
+			t.Fatal("expected the information functions to be applied")
+		}
+	}
+	if e := newError("overridden").Code(404); e.ErrorCode != "404" {
+		t.Fatalf("expected the default code to be overridable, got %s", e.ErrorCode)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	newError := func(line int) *errors.Error {
+		return errors.New("not found").Code(404).AddInfo("line", line).AddInfo("date", time.Now().String()).
+			AddInfo("path", "/users/42").AddInfo("fn", strconv.Itoa(line))
+	}
+	a, b := newError(1), newError(2)
+	if !errors.Equal(a, b, "fn") {
+		t.Fatal("expected errors differing only in ignored keys to be equal")
+	}
+	if errors.Equal(a, b) {
+		t.Fatal("expected the keys that are not ignored by default to be compared")
+	}
+	if errors.Equal(a, b.Clone().AddInfo("path", "/users/43"), "fn") {
+		t.Fatal("expected errors with different information to differ")
+	}
+	if errors.Equal(a, b.Clone().Code(500), "fn") {
+		t.Fatal("expected errors with different codes to differ")
+	}
+	if errors.Equal(a.Wraps(errors.New("no rows")), b.Wraps(errors.New("timeout")), "fn") {
+		t.Fatal("expected errors with different chains to differ")
+	}
+	if !errors.Equal(a.Wraps(errors.New("no rows")), b.Wraps(errors.New("no rows")), "fn") {
+		t.Fatal("expected errors with equal chains to be equal")
+	}
+	if !errors.Equal(nil, nil) || errors.Equal(a, nil) {
+		t.Fatal("unexpected comparison with a nil error")
+	}
+}
+
+func TestString(t *testing.T) {
+	e := errors.New("not found").AddInfo("path", "/users/42")
+	if s := e.String(); s != "not found" {
+		t.Fatalf("expected the bare cause, got %q", s)
+	}
+	if s := e.Code(404).String(); s != "[404] not found" {
+		t.Fatalf("expected the code and cause, got %q", s)
+	}
+	if s := errors.New("timeout").CodeString("E_TIMEOUT").Wraps(e).String(); s != "[E_TIMEOUT] timeout" {
+		t.Fatalf("expected the chain to be left out, got %q", s)
+	}
+	var nilErr *errors.Error
+	if s := nilErr.String(); s != "<nil>" {
+		t.Fatalf("unexpected description of a nil error: %q", s)
+	}
+}
+
+func TestListFlatten(t *testing.T) {
+	std := stderrors.New("std")
+	inner := errors.NewList()
+	inner.Add(errors.New("b"), errors.New("c").Wraps(errors.New("d")))
+
+	l := errors.NewList()
+	l.Add(errors.New("a"), inner, nil, std)
+
+	flat := l.Flatten()
+	want := []string{"a", "b", "c", "d", "std"}
+	if len(flat) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(flat), flat)
+	}
+	for i, err := range flat {
+		var cause string
+		if e, ok := err.(*errors.Error); ok {
+			if e.Underlying != nil {
+				t.Fatalf("expected error %d not to wrap any other error", i)
+			}
+			cause = e.ErrorCause
+		} else {
+			cause = err.Error()
+		}
+		if cause != want[i] {
+			t.Fatalf("expected error %d to be %q, got %q", i, want[i], cause)
+		}
+	}
+
+	l = errors.NewList()
+	l.Add(
+		errors.New("e").Merge(errors.New("f").Wraps(errors.New("g"))),
+		errors.New("h").WrapsAll(errors.New("i"), stderrors.New("j")),
+	)
+	flat = l.Flatten()
+	want = []string{"e; f", "e", "f", "g", "h", "i", "j"}
+	if len(flat) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(flat), flat)
+	}
+	for i, err := range flat {
+		cause := err.Error()
+		if e, ok := err.(*errors.Error); ok {
+			if e.Underlying != nil || len(e.Branches()) > 0 {
+				t.Fatalf("expected error %d not to wrap any other error", i)
+			}
+			cause = e.ErrorCause
+		}
+		if cause != want[i] {
+			t.Fatalf("expected error %d to be %q, got %q", i, want[i], cause)
+		}
+	}
+}
+
+func TestListGroupByCode(t *testing.T) {
+	l := errors.NewList()
+	l.Add(
+		errors.New("timeout 1").CodeString("E_TIMEOUT"),
+		errors.New("not found 1").Code(404),
+		stderrors.New("not an Error"),
+		errors.New("timeout 2").CodeString("E_TIMEOUT"),
+		errors.New("no code"),
+		errors.New("timeout 3").CodeString("E_TIMEOUT"),
+		errors.New("not found 2").Code(404),
+	)
+	groups := l.GroupByCode()
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d: %v", len(groups), groups)
+	}
+	if len(groups["E_TIMEOUT"]) != 3 || len(groups["404"]) != 2 || len(groups[""]) != 1 {
+		t.Fatalf("unexpected group sizes: %v", groups)
+	}
+	if groups["404"][0].ErrorCause != "not found 1" || groups["404"][1].ErrorCause != "not found 2" {
+		t.Fatal("expected the order of the list to be preserved within a group")
+	}
+}
+
+func TestListAnyAll(t *testing.T) {
+	unavailable := func(err error) bool { return errors.As(err).HasCode(503) }
+	temporary := func(err error) bool { return errors.As(err).Temporary() }
+
+	empty := errors.NewList()
+	if empty.Any(unavailable) {
+		t.Fatal("expected Any to be false over an empty list")
+	}
+	if !empty.All(temporary) {
+		t.Fatal("expected All to be true over an empty list")
+	}
+
+	l := errors.NewList()
+	l.Add(errors.New("busy").Code(503).SetTemporary(true), errors.New("timeout").Code(504).SetTemporary(true))
+	if !l.Any(unavailable) || !l.All(temporary) {
+		t.Fatal("expected the predicates to hold")
+	}
+	l.Add(errors.New("not found").Code(404))
+	if !l.Any(unavailable) || l.All(temporary) {
+		t.Fatal("expected All to fail once a value does not satisfy the predicate")
+	}
+	if l.Any(func(err error) bool { return errors.As(err).HasCode(500) }) {
+		t.Fatal("expected Any to fail when no value satisfies the predicate")
+	}
+}
+
+func TestRequireInfo(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec,
+		errors.WithInfoFuncs(errors.PrintLine),
+		errors.RequireInfo("line"),
+	)
+	if e := newError("satisfied"); e.HasInfo("info_error") {
+		t.Fatalf("expected the requirement to be satisfied, got %v", e.ErrorInfo["info_error"])
+	}
+
+	newError = errors.Constructor(errors.JSONCodec,
+		errors.WithInfoFuncs(errors.PrintLine),
+		errors.RequireInfo("line", "request_id", "user"),
+	)
+	e := newError("unsatisfied")
+	if v, _ := e.GetString("info_error"); v != "missing required information: request_id, user" {
+		t.Fatalf("expected the missing keys to be reported, got %q", v)
+	}
+}
+
+func TestGetDate(t *testing.T) {
+	before := time.Now().Add(-time.Second)
+	infoFuncs := map[string]func() (string, interface{}){
+		"date":      errors.PrintDate,
+		"timestamp": errors.PrintTimestamp,
+		"epoch":     errors.PrintEpoch,
+	}
+	for name, f := range infoFuncs {
+		e := errors.Constructor(errors.JSONCodec, errors.WithInfoFuncs(f))("failed")
+		date, ok := e.GetDate()
+		if !ok || date.Before(before) || date.After(time.Now()) {
+			t.Fatalf("%s: unexpected date %v", name, date)
+		}
+		d := errors.JSONCodec.Decode([]byte(e.Error()))
+		decoded, ok := d.GetDate()
+		if !ok || !decoded.Equal(date) {
+			t.Fatalf("%s: expected the date %v to survive the round trip, got %v", name, date, decoded)
+		}
+	}
+
+	e := errors.New("failed")
+	if _, ok := e.GetDate(); ok {
+		t.Fatal("expected no date")
+	}
+	if _, ok := e.AddInfo("date", "yesterday").GetDate(); ok {
+		t.Fatal("expected an unparsable date to be reported")
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	fixed := time.Date(2009, time.November, 10, 23, 0, 0, 123456789, time.UTC)
+	errors.SetClock(func() time.Time { return fixed })
+	defer errors.SetClock(nil)
+
+	e := errors.Constructor(errors.CompactJSONCodec, errors.WithInfoFuncs(errors.PrintTimestamp))("failed")
+	if got, want := e.Error(), `{"ErrorInfo":{"date":"2009-11-10T23:00:00.123456789Z"},"ErrorCause":"failed"}`; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if _, date := errors.PrintDate(); date != "Tue Nov 10 23:00:00 UTC 2009" {
+		t.Fatalf("unexpected date: %v", date)
+	}
+
+	errors.SetClock(nil)
+	if _, date := errors.PrintEpoch(); date.(int64) <= fixed.UnixMilli() {
+		t.Fatalf("expected the system clock to be restored, got %v", date)
+	}
+}
+
+func TestCodeInt(t *testing.T) {
+	classify := func(e *errors.Error) string {
+		code, ok := e.CodeInt()
+		if !ok {
+			return "unknown"
+		}
+		switch code {
+		case 404:
+			return "not found"
+		case 500:
+			return "internal"
+		}
+		return "other"
+	}
+	cases := map[string]*errors.Error{
+		"not found": errors.New("missing").Code(404),
+		"internal":  errors.New("failed").Code(500),
+		"other":     errors.New("teapot").Code(418),
+		"unknown":   errors.New("deadline exceeded").CodeString("E_TIMEOUT"),
+	}
+	for want, e := range cases {
+		if got := classify(e); got != want {
+			t.Fatalf("expected %q for %v, got %q", want, e, got)
+		}
+	}
+	if _, ok := errors.New("no code").CodeInt(); ok {
+		t.Fatal("expected ok to be false without a code")
+	}
+}
+
+func TestAddInfos(t *testing.T) {
+	e := errors.Constructor(errors.JSONCodec)("failed")
+	if e.AddInfos(nil).ErrorInfo != nil {
+		t.Fatal("expected the information map to be allocated lazily")
+	}
+	e.AddInfo("user", "alice").AddInfo("attempt", 1)
+	e.AddInfos(map[string]interface{}{"attempt": 2, "host": "db1"})
+	if len(e.ErrorInfo) != 3 || e.ErrorInfo["user"] != "alice" || e.ErrorInfo["attempt"] != 2 || e.ErrorInfo["host"] != "db1" {
+		t.Fatalf("unexpected information: %v", e.ErrorInfo)
+	}
+
+	e = errors.Constructor(errors.JSONCodec)("failed").WithFields(map[string]interface{}{"a": 1})
+	if e.ErrorInfo["a"] != 1 {
+		t.Fatalf("unexpected information: %v", e.ErrorInfo)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	load := func(fail error) (err error) {
+		defer errors.Wrap(&err, "loading config")
+		return fail
+	}
+	if err := load(nil); err != nil {
+		t.Fatalf("expected a nil error to be left as is, got %v", err)
+	}
+
+	std := stderrors.New("permission denied")
+	e := errors.As(load(std))
+	if e == nil || e.ErrorCause != "loading config" || !stderrors.Is(e, std) {
+		t.Fatalf("expected the standard error to be wrapped, got %v", e)
+	}
+
+	cause := errors.New("open config.json").Wraps(std)
+	e = errors.As(load(cause))
+	if e.Underlying != cause || e.FullMessage() != "loading config: open config.json: permission denied" || !stderrors.Is(e, std) {
+		t.Fatalf("expected the chain to be preserved, got %v", e.FullMessage())
+	}
+	if line, _ := e.GetInt("line"); line == 0 {
+		t.Fatal("expected the default information to be recorded")
+	}
+}
+
+func TestInheritInfo(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	root := newError("connection reset").AddInfo("request_id", "req-42").AddInfo("host", "db1")
+	mid := newError("query failed").AddInfo("host", "db2").Wraps(root)
+
+	e := newError("checkout failed").AddInfo("user", "alice").Wraps(mid).InheritInfo("request_id", "host", "user", "missing")
+	if e.ErrorInfo["request_id"] != "req-42" {
+		t.Fatalf("expected the request id of the root cause to be inherited, got %v", e.ErrorInfo)
+	}
+	if e.ErrorInfo["host"] != "db2" {
+		t.Fatal("expected the value of the outermost wrapped error to win")
+	}
+	if e.ErrorInfo["user"] != "alice" || e.HasInfo("missing") {
+		t.Fatalf("unexpected information: %v", e.ErrorInfo)
+	}
+	if len(e.ErrorInfo) != 3 {
+		t.Fatal("expected only the given keys to be inherited")
+	}
+
+	if newError("alone").InheritInfo("request_id").HasInfo("request_id") {
+		t.Fatal("expected nothing to be inherited without a wrapped error")
+	}
+}
+
+func TestTruncateCause(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	long := strings.Repeat("données é🚀 ", 100)
+
+	e := newError(long).TruncateCause(10)
+	if e.ErrorCause != "données é🚀"+errors.Ellipsis {
+		t.Fatalf("unexpected cause: %q", e.ErrorCause)
+	}
+	if !utf8.ValidString(e.ErrorCause) {
+		t.Fatal("expected the runes to be kept whole")
+	}
+	if c := newError("é🚀").TruncateCause(2).ErrorCause; c != "é🚀" {
+		t.Fatalf("expected a short cause to be left untouched, got %q", c)
+	}
+	if c := newError(long).TruncateCause(0).ErrorCause; c != long {
+		t.Fatal("expected a zero max to leave the cause untouched")
+	}
+
+	w := newError("outer message").Wraps(newError(long))
+	w.TruncateCause(5)
+	if w.ErrorCause != "outer"+errors.Ellipsis || w.Underlying.ErrorCause != long {
+		t.Fatalf("expected only the receiver to be truncated, got %q", w.FullMessage())
+	}
+	w.TruncateCauses(5)
+	if w.Underlying.ErrorCause != "donné"+errors.Ellipsis {
+		t.Fatalf("expected the chain to be truncated, got %q", w.Underlying.ErrorCause)
+	}
+	m := newError("primary").Merge(newError(long))
+	m.TruncateCauses(5)
+	if m.Causes()[1].ErrorCause != "donné"+errors.Ellipsis {
+		t.Fatalf("expected the merged causes to be truncated, got %q", m.Causes()[1].ErrorCause)
+	}
+}
+
+func TestIsAny(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	e := newError("not found").Code(404)
+	if !e.IsAny(400, 404, 410) {
+		t.Fatal("expected a match")
+	}
+	if e.IsAny(500, 503) || e.IsAny() {
+		t.Fatal("expected no match")
+	}
+	if newError("no code").IsAny(0) || newError("symbolic").CodeString("E_TIMEOUT").IsAny(0, 404) {
+		t.Fatal("expected an error without a numeric code not to match")
+	}
+
+	chain := newError("fetching profile").Code(500).Wraps(newError("unavailable").Code(503).Wraps(e))
+	if chain.IsAny(404, 503) {
+		t.Fatal("expected IsAny to only consider the receiver")
+	}
+	if !chain.IsAnyInChain(404) || !chain.IsAnyInChain(410, 503) {
+		t.Fatal("expected a match in the chain")
+	}
+	if chain.IsAnyInChain(400, 410) {
+		t.Fatal("expected no match in the chain")
+	}
+	if !newError("retry failed").Merge(chain).IsAnyInChain(404) {
+		t.Fatal("expected a match in the merged causes")
+	}
+	var nilError *errors.Error
+	if nilError.IsAny(404) || nilError.IsAnyInChain(404) {
+		t.Fatal("expected a nil error not to match")
+	}
+}
+
+func TestCoder(t *testing.T) {
+	err := fmt.Errorf("handling request: %w", errors.Constructor(errors.JSONCodec)("not found").Code(404))
+	var c errors.Coder
+	if !stderrors.As(err, &c) {
+		t.Fatal("expected the Error to be found as a Coder")
+	}
+	if code, ok := c.CodeInt(); !ok || code != 404 {
+		t.Fatalf("expected code 404, got %d", code)
+	}
+
+	if stderrors.As(fmt.Errorf("wrapping: %w", io.EOF), &c) {
+		t.Fatal("expected a standard error not to be a Coder")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	primary := newError("primary failed").Code(503).AddInfo("host", "db1").Wraps(newError("connection reset"))
+	fallback := newError("fallback failed").Code(504).AddInfo("host", "db2").AddInfo("password", "hunter2")
+
+	m := primary.Merge(fallback)
+	if m.ErrorCause != "primary failed; fallback failed" || m.Underlying != nil {
+		t.Fatalf("unexpected merged error: %#v", m)
+	}
+	if c := m.Causes(); len(c) != 2 || c[0] != primary || c[1] != fallback {
+		t.Fatalf("expected both errors to be held as causes, got %v", c)
+	}
+	if primary.Merge(nil) != primary || (*errors.Error)(nil).Merge(fallback) != fallback {
+		t.Fatal("expected the other error to be returned when one is nil")
+	}
+
+	c := m.Clone()
+	c.ErrorCauses[1].AddInfo("host", "db3")
+	if fallback.ErrorInfo["host"] != "db2" {
+		t.Fatal("expected the clone to copy the merged causes")
+	}
+
+	for name, codec := range map[string]errors.Codec{"json": errors.JSONCodec, "xml": errors.XMLCodec, "gob": errors.GobCodec} {
+		b, err := codec.Encode(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := codec.Decode(b)
+		if !errors.Equal(d, m) {
+			t.Fatalf("%s: expected the merged causes to survive the round trip, got %s", name, b)
+		}
+		if d.Causes()[0].Underlying.ErrorCause != "connection reset" {
+			t.Fatalf("%s: expected the chain of the merged causes to be kept", name)
+		}
+	}
+	if errors.Equal(m, primary.Merge(newError("fallback failed").Code(504))) {
+		t.Fatal("expected merged causes with different information to differ")
+	}
+
+	if s := m.Redact("password").Error(); strings.Contains(s, "hunter2") {
+		t.Fatalf("expected the merged causes to be redacted, got %s", s)
+	}
+}
+
+func TestMergeSharedRoot(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	root := newError("db down")
+	m := newError("primary failed").Wraps(root).Merge(newError("fallback failed").Wraps(root))
+	tree := newError("batch failed").WrapsAll(newError("item 1").Wraps(root), newError("item 2").Wraps(root))
+
+	for _, e := range []*errors.Error{m, tree} {
+		c := e.Clone()
+		if c.Causes()[0].Underlying == nil || c.Causes()[1].Underlying == nil {
+			t.Fatalf("expected the shared root to be copied under both causes, got %#v", c.Causes())
+		}
+		if s := e.Error(); strings.Count(s, "db down") != 2 {
+			t.Fatalf("expected the shared root to be serialized under both causes, got %s", s)
+		}
+		for name, codec := range map[string]errors.Codec{"xml": errors.XMLCodec, "gob": errors.GobCodec} {
+			b, err := codec.Encode(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := codec.Decode(b); !errors.Equal(d, e) {
+				t.Fatalf("%s: expected the shared root to survive the round trip, got %s", name, b)
+			}
+		}
+	}
+}
+
+type temporaryError struct{ msg string }
+
+func (t *temporaryError) Error() string { return t.msg }
+
+func TestWrapsAll(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	notFound := newError("not found").Code(404)
+	tmp := &temporaryError{"try again"}
+
+	tree := newError("batch failed").WrapsAll(
+		newError("item 1").Code(400),
+		nil,
+		fmt.Errorf("item 2: %w", io.ErrUnexpectedEOF),
+		newError("item 3").Wraps(newError("lookup failed").Wraps(notFound)),
+		newError("item 4").Wraps(tmp),
+	)
+	if tree.Underlying != nil || tree.Source() != nil || len(tree.Branches()) != 4 {
+		t.Fatalf("expected 4 branches, got %v", tree.Branches())
+	}
+	var multi interface{ Unwrap() []error } = tree
+	if errs := multi.Unwrap(); len(errs) != 4 || errs[0] != tree.Branches()[0] {
+		t.Fatalf("expected the branches to be unwrapped, got %v", errs)
+	}
+	outer := newError("job failed").Wraps(tree)
+
+	for _, target := range []error{io.ErrUnexpectedEOF, errors.New("any").Code(404), errors.New("any").Code(400)} {
+		if !stderrors.Is(outer, target) {
+			t.Fatalf("expected %v to be found in a branch", target)
+		}
+	}
+	if stderrors.Is(outer, io.EOF) || stderrors.Is(outer, errors.New("any").Code(500)) {
+		t.Fatal("expected no match")
+	}
+	var te *temporaryError
+	if !stderrors.As(outer, &te) || te != tmp {
+		t.Fatal("expected errors.As to find the error in a branch")
+	}
+
+	s := outer.Error()
+	for _, want := range []string{"item 1", "item 2: unexpected EOF", "lookup failed", "not found"} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected the branches to be serialized, missing %q in %s", want, s)
+		}
+	}
+
+	l := errors.NewList()
+	l.Add(newError("first"), io.EOF)
+	w := newError("wrapping a list").Wraps(l)
+	if len(w.Branches()) != 2 || !stderrors.Is(w, io.EOF) {
+		t.Fatalf("expected the values of the list to be wrapped as branches, got %v", w.Branches())
+	}
+
+	single := newError("single").WrapsAll(nil, io.EOF)
+	if single.Source() != io.EOF || single.Branches() != nil {
+		t.Fatal("expected a single error to be wrapped as the underlying error")
+	}
+}
+
+func TestNewListSeeded(t *testing.T) {
+	if l := errors.NewList(); l.Values == nil || l.Len() != 0 {
+		t.Fatal("expected an empty list")
+	}
+
+	var nilError *errors.Error
+	first, second := errors.New("first"), stderrors.New("second")
+	l := errors.NewList(nil, first, nilError, second, nil)
+	if l.Len() != 2 || l.Values[0] != first || l.Values[1] != second {
+		t.Fatalf("expected the nil errors to be skipped, got %v", l.Values)
+	}
+	l.Add(errors.New("third"))
+	if l.Len() != 3 {
+		t.Fatal("expected the seeded list to accept new errors")
+	}
+}
+
+func TestListErrorOrNil(t *testing.T) {
+	validate := func(values ...string) error {
+		l := errors.NewList()
+		for _, v := range values {
+			if v == "" {
+				l.Add(errors.New("empty value"))
+			}
+		}
+		return l.ErrorOrNil()
+	}
+	if err := validate("a", "b"); err != nil {
+		t.Fatalf("expected nil for an empty list, got %#v", err)
+	}
+	err := validate("a", "", "")
+	l, ok := err.(*errors.List)
+	if !ok || l.Len() != 2 {
+		t.Fatalf("expected the list to be returned, got %#v", err)
+	}
+	var nilList *errors.List
+	if nilList.ErrorOrNil() != nil {
+		t.Fatal("expected nil for a nil list")
+	}
+}
+
+func TestListPlainErrorHeader(t *testing.T) {
+	newError := errors.Constructor(errors.CompactJSONCodec)
+	l := errors.NewList()
+	if l.PlainError() != "" {
+		t.Fatal("expected an empty output for an empty list")
+	}
+	l.Add(newError("first"), nil)
+	if p := l.PlainError(); !strings.HasPrefix(p, "1 error occurred:\n") {
+		t.Fatalf("unexpected output: %q", p)
+	}
+	l.Add(newError("second"), stderrors.New("third"))
+	p := l.PlainError()
+	if !strings.HasPrefix(p, "3 errors occurred:\n") || !strings.HasSuffix(p, "\nthird\n") || strings.Count(p, "\n") != 4 {
+		t.Fatalf("unexpected output: %q", p)
+	}
+
+	l.HeaderFormat = "validation failed with %d errors"
+	if p := l.PlainError(); !strings.HasPrefix(p, "validation failed with 3 errors\n") {
+		t.Fatalf("expected the custom header, got %q", p)
+	}
+}
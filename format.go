@@ -0,0 +1,249 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Format implements the fmt.Formatter interface.
+//
+// The following verbs are supported:
+//
+//	%s    the cause of the error
+//	%q    the quoted cause of the error
+//	%v    the causes of the whole chain, separated by colons
+//	%+v   the whole chain, with the code, severity, information and stack
+//	      trace of every error
+//
+// The serialized form of an error is still available via its Error method.
+func (e *Error) Format(s fmt.State, verb rune) {
+	if e == nil {
+		io.WriteString(s, "<nil>")
+		return
+	}
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, e.verbose())
+			return
+		}
+		io.WriteString(s, e.message())
+	case 's':
+		io.WriteString(s, e.ErrorCause)
+	case 'q':
+		fmt.Fprintf(s, "%q", e.ErrorCause)
+	default:
+		fmt.Fprintf(s, "%%!%c(*errors.Error=%s)", verb, e.ErrorCause)
+	}
+}
+
+// message returns the causes of the chain of errors, from the outermost to the
+// innermost, separated by colons.
+func (e *Error) message() string {
+	causes := make([]string, 0, 1)
+	e.Walk(func(err *Error) bool {
+		causes = append(causes, err.ErrorCause)
+		if err.Underlying == nil && err.underlyingStd != nil {
+			causes = append(causes, err.underlyingStd.Error())
+		}
+		return true
+	})
+	return strings.Join(causes, ": ")
+}
+
+// verbose returns a multiline description of the chain of errors.
+func (e *Error) verbose() string {
+	var b strings.Builder
+	e.Walk(func(err *Error) bool {
+		if err != e {
+			b.WriteString("caused by: ")
+		}
+		b.WriteString(err.ErrorCause)
+		b.WriteByte('\n')
+		if err.ErrorCode != "" {
+			fmt.Fprintf(&b, "    code: %s\n", err.ErrorCode)
+		}
+		if err.ErrorSeverity != 0 {
+			fmt.Fprintf(&b, "    severity: %s\n", err.ErrorSeverity)
+		}
+		err.mu.RLock()
+		if err.detail != "" {
+			fmt.Fprintf(&b, "    detail: %s\n", err.detail)
+		}
+		keys := make([]string, 0, len(err.ErrorInfo))
+		for k := range err.ErrorInfo {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			frames, ok := err.ErrorInfo[k].([]Frame)
+			if !ok {
+				fmt.Fprintf(&b, "    %s: %v\n", k, err.ErrorInfo[k])
+				continue
+			}
+			fmt.Fprintf(&b, "    %s:\n", k)
+			writeFrames(&b, frames)
+		}
+		err.mu.RUnlock()
+		if len(err.ErrorStack) > 0 {
+			b.WriteString("    stack:\n")
+			writeFrames(&b, err.ErrorStack)
+		}
+		for _, m := range err.allCauses() {
+			fmt.Fprintf(&b, "    merged: %s\n", m.FullMessage())
+		}
+		if err.Underlying == nil && err.underlyingStd != nil {
+			fmt.Fprintf(&b, "caused by: %s\n", err.underlyingStd.Error())
+		}
+		return true
+	})
+	return b.String()
+}
+
+func writeFrames(b *strings.Builder, frames []Frame) {
+	for _, f := range frames {
+		fmt.Fprintf(b, "        %s\n            %s:%d\n", f.Func, f.File, f.Line)
+	}
+}
+
+// Pretty returns a human readable outline of the error, meant for command line
+// output rather than for machines: the outermost error comes first, followed
+// by one "caused by:" line per underlying error, each indented one step
+// further, e.g.
+//
+//	[500] loading profile (component=api)
+//	  caused by: query failed
+//	    caused by: connection refused
+//
+// Every line holds the severity and the code, if any, the cause and the
+// information, sorted by key. Stack traces are left out. The branches of a
+// tree of errors are rendered after the underlying error, at the same level.
+func (e *Error) Pretty() string {
+	return e.prettyString(false)
+}
+
+// PrettyColor returns the outline returned by Pretty in which the codes and
+// the severities are highlighted with ANSI escape codes, for interactive
+// terminals. If coloring is disabled, see SetColor, it returns the same output
+// as Pretty.
+func (e *Error) PrettyColor() string {
+	return e.prettyString(ColorEnabled())
+}
+
+// ANSI escape codes used by PrettyColor.
+const (
+	ansiReset = "\x1b[0m"
+	ansiCode  = "\x1b[1;36m"
+)
+
+var severityColors = map[Severity]string{
+	SeverityDebug: "\x1b[90m",
+	SeverityInfo:  "\x1b[34m",
+	SeverityWarn:  "\x1b[33m",
+	SeverityError: "\x1b[31m",
+	SeverityFatal: "\x1b[1;31m",
+}
+
+// color holds whether PrettyColor uses colors. It is enabled by default when
+// the standard output is a terminal and the NO_COLOR environment variable is
+// not set.
+var color struct {
+	sync.RWMutex
+	enabled bool
+}
+
+func init() {
+	fi, err := os.Stdout.Stat()
+	color.enabled = err == nil && fi.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == ""
+}
+
+// SetColor enables or disables the colors of PrettyColor, overriding the
+// detection of a terminal.
+func SetColor(enabled bool) {
+	color.Lock()
+	color.enabled = enabled
+	color.Unlock()
+}
+
+// ColorEnabled reports whether PrettyColor uses colors.
+func ColorEnabled() bool {
+	color.RLock()
+	defer color.RUnlock()
+	return color.enabled
+}
+
+// colorize wraps s in the given ANSI escape code if colored is true.
+func colorize(s, code string, colored bool) string {
+	if !colored || code == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (e *Error) prettyString(colored bool) string {
+	if e == nil {
+		return "<nil>"
+	}
+	var b strings.Builder
+	e.pretty(&b, 0, colored, make(map[*Error]bool))
+	return b.String()
+}
+
+// pretty writes the error, then the errors it wraps, one level of indentation
+// deeper. An Error shared by several branches is written under each of them.
+// path holds the Errors being written, from the receiver up to the outermost
+// one, so that a cycle is left out.
+func (e *Error) pretty(b *strings.Builder, depth int, colored bool, path map[*Error]bool) {
+	if path[e] {
+		return
+	}
+	path[e] = true
+	defer delete(path, e)
+	b.WriteString(strings.Repeat("  ", depth))
+	if depth > 0 {
+		b.WriteString("caused by: ")
+	}
+	if e.ErrorSeverity != 0 {
+		b.WriteString(colorize(strings.ToUpper(e.ErrorSeverity.String()), severityColors[e.ErrorSeverity], colored))
+		b.WriteByte(' ')
+	}
+	if e.ErrorCode != "" {
+		b.WriteString(colorize("["+e.ErrorCode+"]", ansiCode, colored))
+		b.WriteByte(' ')
+	}
+	b.WriteString(e.ErrorCause)
+	e.mu.RLock()
+	keys := make([]string, 0, len(e.ErrorInfo))
+	for k, v := range e.ErrorInfo {
+		if _, ok := v.([]Frame); !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteString(" (")
+		} else {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(b, "%s=%v", k, e.ErrorInfo[k])
+	}
+	e.mu.RUnlock()
+	if len(keys) > 0 {
+		b.WriteByte(')')
+	}
+	b.WriteByte('\n')
+
+	if e.Underlying != nil {
+		e.Underlying.pretty(b, depth+1, colored, path)
+	} else if e.underlyingStd != nil {
+		fmt.Fprintf(b, "%scaused by: %s\n", strings.Repeat("  ", depth+1), e.underlyingStd.Error())
+	}
+	for _, c := range e.allCauses() {
+		c.pretty(b, depth+1, colored, path)
+	}
+}
@@ -0,0 +1,100 @@
+package errors_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestFormat(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	root := newError("connection refused").AddInfo(errors.PrintTrace())
+	e := newError("loading profile").Code(500).AddInfo("component", "api").
+		Wraps(newError("query failed").Wraps(root))
+
+	if s := fmt.Sprintf("%s", e); s != "loading profile" {
+		t.Fatalf("unexpected %%s output: %q", s)
+	}
+	if s := fmt.Sprintf("%q", e); s != `"loading profile"` {
+		t.Fatalf("unexpected %%q output: %q", s)
+	}
+	if s := fmt.Sprintf("%v", e); s != "loading profile: query failed: connection refused" {
+		t.Fatalf("unexpected %%v output: %q", s)
+	}
+
+	s := fmt.Sprintf("%+v", e)
+	for _, want := range []string{
+		"loading profile\n    code: 500\n    component: api\n",
+		"caused by: query failed\n",
+		"caused by: connection refused\n    trace:\n        github.com/atdiar/errors_test.TestFormat\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected %%+v output to contain %q, got:\n%s", want, s)
+		}
+	}
+}
+
+func TestPretty(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	root := newError("connection refused").AddInfo("host", "db1").AddInfo("port", 5432).AddInfo(errors.PrintTrace())
+	e := newError("loading profile").Code(500).AddInfo("component", "api").
+		Wraps(newError("query failed").CodeString("E_QUERY").Wraps(root))
+
+	want := "[500] loading profile (component=api)\n" +
+		"  caused by: [E_QUERY] query failed\n" +
+		"    caused by: connection refused (host=db1, port=5432)\n"
+	if s := e.Pretty(); s != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, s)
+	}
+
+	tree := newError("batch failed").WrapsAll(newError("item 1"), io.EOF)
+	want = "batch failed\n  caused by: item 1\n  caused by: EOF\n"
+	if s := tree.Pretty(); s != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, s)
+	}
+
+	shared := newError("db down")
+	m := newError("primary failed").Wraps(shared).Merge(newError("fallback failed").Wraps(shared))
+	want = "primary failed; fallback failed\n" +
+		"  caused by: primary failed\n    caused by: db down\n" +
+		"  caused by: fallback failed\n    caused by: db down\n"
+	if s := m.Pretty(); s != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, s)
+	}
+
+	a, c := newError("a"), newError("c")
+	a.Underlying = c
+	c.Underlying = a
+	if want := "a\n  caused by: c\n"; a.Pretty() != want {
+		t.Fatalf("expected the cycle to be left out, got:\n%s", a.Pretty())
+	}
+}
+
+func TestPrettyColor(t *testing.T) {
+	defer errors.SetColor(errors.ColorEnabled())
+	newError := errors.Constructor(errors.JSONCodec)
+	e := newError("loading profile").Code(500).Severity(errors.SeverityFatal).
+		Wraps(newError("disk almost full").Severity(errors.SeverityWarn))
+
+	errors.SetColor(true)
+	s := e.PrettyColor()
+	for _, want := range []string{"\x1b[1;31mFATAL\x1b[0m", "\x1b[1;36m[500]\x1b[0m", "\x1b[33mWARN\x1b[0m disk almost full"} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected %q in the colored output, got %q", want, s)
+		}
+	}
+	if strings.Contains(e.Pretty(), "\x1b[") {
+		t.Fatal("expected Pretty to remain uncolored")
+	}
+
+	errors.SetColor(false)
+	if s := e.PrettyColor(); strings.Contains(s, "\x1b[") || s != e.Pretty() {
+		t.Fatalf("expected no color when disabled, got %q", s)
+	}
+	if want := "FATAL [500] loading profile\n  caused by: WARN disk almost full\n"; e.Pretty() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, e.Pretty())
+	}
+}
@@ -0,0 +1,145 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sort"
+)
+
+// GobCodec is an Error Encoder/Decoder object using encoding/gob as the
+// serialization format. It produces a compact binary representation suited for
+// transport between Go services.
+//
+// The concrete types of the values stored as information need to be known by
+// gob. Basic types are registered by default. Other types should be registered
+// with RegisterGobType by both the sender and the receiver.
+// If the information cannot be encoded, it is sent as text instead.
+// Information entries are sent sorted by key so that the encoding of an error
+// is deterministic.
+// A wrapped error that is not of type Error is encoded as an Error holding its
+// message.
+// If a payload cannot be decoded, the returned Error holds the decoding failure
+// as its cause.
+var GobCodec Codec
+
+func init() {
+	GobCodec = NewCodec(toGob, fromGob)
+	RegisterGobType([]Frame{})
+}
+
+// RegisterGobType records the concrete type of value so that information
+// entries of this type can be sent with the GobCodec.
+func RegisterGobType(value interface{}) {
+	gob.Register(value)
+}
+
+type gobError struct {
+	Info     []gobInfo
+	Code     string
+	Cause    string
+	Severity int
+	Stack    []Frame
+	Source   *gobError
+	Causes   []*gobError
+}
+
+// gobInfo is an information entry. The entries are sent as a list sorted by
+// key, rather than as a map, so that the encoding is deterministic.
+type gobInfo struct {
+	Key   string
+	Value interface{}
+}
+
+// newGobError converts e and the errors it wraps. As with Clone, an Error
+// reachable through several branches is converted once and a cycle is left
+// out.
+func newGobError(e *Error, stringify bool, converted map[*Error]*gobError) *gobError {
+	if e == nil {
+		return nil
+	}
+	if g, ok := converted[e]; ok {
+		return g
+	}
+	converted[e] = nil
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	g := &gobError{
+		Code:     e.ErrorCode,
+		Cause:    e.ErrorCause,
+		Severity: int(e.ErrorSeverity),
+		Stack:    e.ErrorStack,
+		Source:   newGobError(e.Underlying, stringify, converted),
+	}
+	if e.Underlying == nil && e.underlyingStd != nil {
+		g.Source = &gobError{Cause: e.underlyingStd.Error()}
+	}
+	for _, m := range e.allCauses() {
+		if gm := newGobError(m, stringify, converted); gm != nil {
+			g.Causes = append(g.Causes, gm)
+		}
+	}
+	keys := make([]string, 0, len(e.ErrorInfo))
+	for k := range e.ErrorInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := e.ErrorInfo[k]
+		if stringify {
+			v = fmt.Sprint(v)
+		}
+		g.Info = append(g.Info, gobInfo{k, v})
+	}
+	converted[e] = g
+	return g
+}
+
+func (g *gobError) toError() *Error {
+	if g == nil {
+		return nil
+	}
+	e := &Error{
+		ErrorCode:     g.Code,
+		ErrorCause:    g.Cause,
+		ErrorSeverity: Severity(g.Severity),
+		ErrorStack:    g.Stack,
+		Underlying:    g.Source.toError(),
+		codec:         GobCodec,
+	}
+	for _, info := range g.Info {
+		e.AddInfo(info.Key, info.Value)
+	}
+	for _, m := range g.Causes {
+		e.ErrorCauses = append(e.ErrorCauses, m.toError())
+	}
+	return e
+}
+
+// toGob will enable the encoding of an Error in the gob format.
+func toGob(i interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	e, ok := i.(*Error)
+	if !ok {
+		err := gob.NewEncoder(&buf).Encode(i)
+		return buf.Bytes(), err
+	}
+	err := gob.NewEncoder(&buf).Encode(newGobError(e, false, make(map[*Error]*gobError)))
+	if err == nil {
+		return buf.Bytes(), nil
+	}
+	// Some information values are of unregistered types.
+	buf.Reset()
+	err = gob.NewEncoder(&buf).Encode(newGobError(e, true, make(map[*Error]*gobError)))
+	return buf.Bytes(), err
+}
+
+// fromGob enables the decoding of a gob payload into an Error object.
+func fromGob(b []byte) *Error {
+	var g gobError
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&g)
+	if err != nil {
+		return &Error{ErrorCause: "errors: unable to decode gob payload: " + err.Error(), codec: GobCodec}
+	}
+	return g.toError()
+}
@@ -0,0 +1,53 @@
+package errors_test
+
+import (
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+type gobEndpoint struct {
+	Host string
+	Port int
+}
+
+type unregistered struct {
+	Name string
+}
+
+func init() {
+	errors.RegisterGobType(gobEndpoint{})
+}
+
+func TestGobCodec(t *testing.T) {
+	newGobError := errors.Constructor(errors.GobCodec)
+	e := newGobError("query failed").Code(500).Severity(errors.SeverityWarn).
+		AddInfo("endpoint", gobEndpoint{"db", 5432}).AddInfo("attempt", 3)
+	e = newGobError("loading profile").AddInfo("user", "42").Wraps(e)
+
+	d := errors.GobCodec.Decode([]byte(e.Error()))
+	if d.ErrorCause != "loading profile" || d.ErrorInfo["user"] != "42" {
+		t.Fatalf("unexpected decoded error: %#v", d)
+	}
+	u := d.Underlying
+	if u == nil || u.ErrorCause != "query failed" || !u.HasCode(500) || u.GetSeverity() != errors.SeverityWarn {
+		t.Fatalf("unexpected decoded underlying error: %#v", u)
+	}
+	if u.ErrorInfo["endpoint"] != (gobEndpoint{"db", 5432}) || u.ErrorInfo["attempt"] != 3 {
+		t.Fatalf("unexpected decoded underlying info: %v", u.ErrorInfo)
+	}
+}
+
+func TestGobCodecUnregisteredType(t *testing.T) {
+	e := errors.Constructor(errors.GobCodec)("failed").AddInfo("value", unregistered{"x"})
+
+	d := errors.GobCodec.Decode([]byte(e.Error()))
+	if d.ErrorCause != "failed" || d.ErrorInfo["value"] != "{x}" {
+		t.Fatalf("expected unregistered values to be sent as text, got %#v", d)
+	}
+
+	bad := errors.GobCodec.Decode([]byte("not gob"))
+	if bad == nil || bad.ErrorCause == "" {
+		t.Fatal("expected an invalid payload to be reported in the cause")
+	}
+}
@@ -0,0 +1,115 @@
+// Package grpcerrors provides conversions between the Error type of the errors
+// package and gRPC statuses, so that the code and the information of an error
+// survive a gRPC call.
+// It lives in its own package so that the errors package does not depend on
+// gRPC.
+package grpcerrors
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/atdiar/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is the domain of the errdetails.ErrorInfo detail carrying the code and
+// the information of an Error.
+const Domain = "github.com/atdiar/errors"
+
+// CodeMapper converts the code of an Error into a gRPC status code.
+// It can be replaced in order to override the default mapping.
+var CodeMapper = DefaultCodeMapper
+
+// httpToGRPC maps the HTTP status codes commonly used as error codes to gRPC
+// status codes.
+var httpToGRPC = map[int]codes.Code{
+	400: codes.InvalidArgument,
+	401: codes.Unauthenticated,
+	403: codes.PermissionDenied,
+	404: codes.NotFound,
+	409: codes.AlreadyExists,
+	412: codes.FailedPrecondition,
+	429: codes.ResourceExhausted,
+	499: codes.Canceled,
+	500: codes.Internal,
+	501: codes.Unimplemented,
+	503: codes.Unavailable,
+	504: codes.DeadlineExceeded,
+}
+
+// grpcCodes maps the names of the gRPC status codes, as returned by their
+// String method, to the codes themselves.
+var grpcCodes = make(map[string]codes.Code)
+
+func init() {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		grpcCodes[c.String()] = c
+	}
+}
+
+// DefaultCodeMapper interprets numeric error codes as HTTP status codes and
+// returns the matching gRPC status code. The names of the gRPC status codes,
+// such as "NotFound", which FromGRPCStatus uses for foreign statuses, map to
+// the codes they name. Any other code maps to codes.Unknown.
+func DefaultCodeMapper(code string) codes.Code {
+	if gc, ok := grpcCodes[code]; ok {
+		return gc
+	}
+	c, err := strconv.Atoi(code)
+	if err != nil {
+		return codes.Unknown
+	}
+	if gc, ok := httpToGRPC[c]; ok {
+		return gc
+	}
+	return codes.Unknown
+}
+
+// ToGRPCStatus converts an Error into a gRPC status. The status code is
+// obtained from CodeMapper and the message is the cause of the error.
+// The error code and the information of the error are stored in an
+// errdetails.ErrorInfo detail. Information values are converted to strings.
+func ToGRPCStatus(e *errors.Error) *status.Status {
+	if e == nil {
+		return nil
+	}
+	st := status.New(CodeMapper(e.ErrorCode), e.ErrorCause)
+	info := &errdetails.ErrorInfo{
+		Reason:   e.ErrorCode,
+		Domain:   Domain,
+		Metadata: make(map[string]string, len(e.ErrorInfo)),
+	}
+	for k, v := range e.Clone().ErrorInfo {
+		info.Metadata[k] = fmt.Sprint(v)
+	}
+	dst, err := st.WithDetails(info)
+	if err != nil {
+		return st
+	}
+	return dst
+}
+
+// FromGRPCStatus converts a gRPC status into an Error. If the status was
+// produced by ToGRPCStatus, the code and the information of the original error
+// are restored. Otherwise, the gRPC status code is used as the error code.
+func FromGRPCStatus(st *status.Status) *errors.Error {
+	if st == nil {
+		return nil
+	}
+	e := errors.Decoded(errors.JSONCodec, st.Message())
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != Domain {
+			continue
+		}
+		e.CodeString(info.GetReason())
+		for k, v := range info.GetMetadata() {
+			e.AddInfo(k, v)
+		}
+		return e
+	}
+	return e.CodeString(st.Code().String())
+}
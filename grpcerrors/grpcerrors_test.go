@@ -0,0 +1,57 @@
+package grpcerrors_test
+
+import (
+	"testing"
+
+	"github.com/atdiar/errors"
+	"github.com/atdiar/errors/grpcerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRoundTrip(t *testing.T) {
+	e := errors.New("user not found").Code(404).AddInfo("user", "42")
+
+	st := grpcerrors.ToGRPCStatus(e)
+	if st.Code() != codes.NotFound || st.Message() != "user not found" {
+		t.Fatalf("unexpected status: %v", st)
+	}
+
+	d := grpcerrors.FromGRPCStatus(st)
+	if !d.HasCode(404) || d.ErrorCause != "user not found" || d.ErrorInfo["user"] != "42" {
+		t.Fatalf("unexpected decoded error: %#v", d)
+	}
+}
+
+func TestCodeMapperOverride(t *testing.T) {
+	defer func(m func(string) codes.Code) { grpcerrors.CodeMapper = m }(grpcerrors.CodeMapper)
+	grpcerrors.CodeMapper = func(code string) codes.Code {
+		if code == "E_TIMEOUT" {
+			return codes.DeadlineExceeded
+		}
+		return grpcerrors.DefaultCodeMapper(code)
+	}
+
+	st := grpcerrors.ToGRPCStatus(errors.New("too slow").CodeString("E_TIMEOUT"))
+	if st.Code() != codes.DeadlineExceeded {
+		t.Fatalf("expected the overridden mapping to be used, got %s", st.Code())
+	}
+	if d := grpcerrors.FromGRPCStatus(st); !d.IsCode("E_TIMEOUT") {
+		t.Fatalf("expected the symbolic code to survive, got %q", d.ErrorCode)
+	}
+}
+
+func TestFromForeignStatus(t *testing.T) {
+	d := grpcerrors.FromGRPCStatus(status.New(codes.Unavailable, "try later"))
+	if d.ErrorCause != "try later" || !d.IsCode("Unavailable") {
+		t.Fatalf("unexpected decoded error: %#v", d)
+	}
+	if st := grpcerrors.ToGRPCStatus(d); st.Code() != codes.Unavailable {
+		t.Fatalf("expected the status code to survive a round trip, got %s", st.Code())
+	}
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if got := grpcerrors.DefaultCodeMapper(c.String()); got != c {
+			t.Fatalf("expected %s to map to itself, got %s", c, got)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package errors
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// createHooks holds the functions registered with OnCreate, as well as the
+// goroutines currently running them.
+var createHooks struct {
+	sync.RWMutex
+	hooks []*createHook
+
+	active sync.Map // goroutine id -> struct{}
+}
+
+// createHook wraps a function registered with OnCreate so that it can be told
+// apart when it is unregistered.
+type createHook struct {
+	fn func(*Error)
+}
+
+// OnCreate registers a function called with every new Error once it is fully
+// initialized, i.e. with the errors produced by a Constructor, New, Newf and
+// Wrapf included, or a ContextConstructor, and with those returned by NewPooled,
+// Merge, Annotate, Join and Recover. Copies, such as those made by Clone or Wraps, are not reported.
+// It allows to maintain metrics, such as a counter by code, without
+// instrumenting every call site.
+// The functions are called synchronously, in the order of registration, and
+// may be called from several goroutines at once. The errors created by the
+// functions themselves do not trigger them again: to that end, the current
+// goroutine is identified each time an error is created, as long as a function
+// is registered.
+// The returned function unregisters fn.
+func OnCreate(fn func(*Error)) (unregister func()) {
+	h := &createHook{fn}
+	createHooks.Lock()
+	defer createHooks.Unlock()
+	createHooks.hooks = append(createHooks.hooks, h)
+	return func() {
+		createHooks.Lock()
+		defer createHooks.Unlock()
+		for i, r := range createHooks.hooks {
+			if r == h {
+				// The slice is copied since notifyCreate may be iterating over it.
+				createHooks.hooks = append(createHooks.hooks[:i:i], createHooks.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notifyCreate calls the functions registered with OnCreate with e, unless the
+// current goroutine is already running them.
+func notifyCreate(e *Error) {
+	createHooks.RLock()
+	hooks := createHooks.hooks
+	createHooks.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+	id := goroutineID()
+	if _, running := createHooks.active.LoadOrStore(id, struct{}{}); running {
+		return
+	}
+	defer createHooks.active.Delete(id)
+	for _, h := range hooks {
+		h.fn(e)
+	}
+}
+
+// withoutHooks calls fn, during which the errors created by the current
+// goroutine do not trigger the functions registered with OnCreate. It allows to
+// report an error once it is fully built.
+func withoutHooks(fn func()) {
+	createHooks.RLock()
+	n := len(createHooks.hooks)
+	createHooks.RUnlock()
+	if n == 0 {
+		fn()
+		return
+	}
+	id := goroutineID()
+	if _, running := createHooks.active.LoadOrStore(id, struct{}{}); running {
+		fn()
+		return
+	}
+	defer createHooks.active.Delete(id)
+	fn()
+}
+
+// goroutineID returns the id of the current goroutine, as found in the header
+// of its stack trace, e.g. "goroutine 7 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
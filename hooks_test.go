@@ -0,0 +1,97 @@
+package errors_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestOnCreate(t *testing.T) {
+	var mu sync.Mutex
+	var created, nested int64
+	byCode := make(map[string]int)
+	t.Cleanup(errors.OnCreate(func(e *errors.Error) {
+		atomic.AddInt64(&created, 1)
+		mu.Lock()
+		byCode[e.ErrorCode]++
+		mu.Unlock()
+		if e.ErrorCause == "reentrant" {
+			// Errors created by a hook must not trigger it again.
+			errors.Constructor(errors.JSONCodec, errors.WithDefaultCode(1))("nested")
+			atomic.AddInt64(&nested, 1)
+		}
+	}))
+
+	before := atomic.LoadInt64(&created)
+	newError := errors.Constructor(errors.JSONCodec, errors.WithDefaultCode(503))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newError("unavailable")
+		}()
+	}
+	wg.Wait()
+	errors.New("reentrant")
+
+	if n := atomic.LoadInt64(&created) - before; n != 11 {
+		t.Fatalf("expected 11 created errors to be reported, got %d", n)
+	}
+	mu.Lock()
+	if byCode["503"] != 10 || byCode["1"] != 0 || nested != 1 {
+		t.Fatalf("unexpected counts: %v", byCode)
+	}
+	mu.Unlock()
+
+	before = atomic.LoadInt64(&created)
+	e := newError("primary failed").Merge(newError("fallback failed")).Annotate("sync failed")
+	errors.Join(e, io.EOF)
+	errors.Recover("boom")
+	errors.ContextConstructor(errors.JSONCodec)(context.Background(), "cancelled")
+	e.Clone().Wraps(io.EOF)
+	errors.Decoded(errors.JSONCodec, "decoded")
+	if n := atomic.LoadInt64(&created) - before; n != 7 {
+		t.Fatalf("expected 7 created errors to be reported, got %d", n)
+	}
+}
+
+func TestOnCreateComplete(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	t.Cleanup(errors.OnCreate(func(e *errors.Error) {
+		source := "<none>"
+		if err := e.Source(); err != nil {
+			source = err.Error()
+		}
+		mu.Lock()
+		seen = append(seen, e.ErrorCause+": "+source)
+		mu.Unlock()
+	}))
+
+	errors.Wrapf(io.EOF, "reading %s", "config.json")
+	p := errors.NewPooled("pooled")
+	defer errors.Release(p)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 || seen[0] != "reading config.json: EOF" || seen[1] != "pooled: <none>" {
+		t.Fatalf("expected the errors to be reported once, fully built, got %q", seen)
+	}
+}
+
+func TestOnCreateUnregister(t *testing.T) {
+	var created int64
+	unregister := errors.OnCreate(func(*errors.Error) { atomic.AddInt64(&created, 1) })
+	errors.New("counted")
+	unregister()
+	unregister()
+	errors.New("not counted")
+	if n := atomic.LoadInt64(&created); n != 1 {
+		t.Fatalf("expected the hook to stop being called once unregistered, got %d calls", n)
+	}
+}
@@ -0,0 +1,36 @@
+package errors
+
+import (
+	"net/http"
+)
+
+// HTTPStatus sets the HTTP status code that should be used when the error is
+// sent in an HTTP response.
+func (e *Error) HTTPStatus(code int) *Error {
+	e.httpStatus = code
+	return e
+}
+
+// StatusCode returns the HTTP status code of the error. It defaults to
+// http.StatusInternalServerError when no status was set.
+func (e *Error) StatusCode() int {
+	if e == nil || e.httpStatus == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.httpStatus
+}
+
+// WriteHTTP writes the error to an HTTP response, using its status code and its
+// JSON serialization as the body.
+func (e *Error) WriteHTTP(w http.ResponseWriter) error {
+	c := e.Clone()
+	c.applyRedactor()
+	body, err := toJSON(c)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(e.StatusCode())
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,34 @@
+package errors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestWriteHTTP(t *testing.T) {
+	e := errors.New("user not found").Code(1001).HTTPStatus(http.StatusNotFound).AddInfo("user", "42")
+
+	rec := httptest.NewRecorder()
+	if err := e.WriteHTTP(rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	d := errors.JSONCodec.Decode(rec.Body.Bytes())
+	if d.ErrorCause != "user not found" || d.ErrorInfo["user"] != "42" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestStatusCodeDefault(t *testing.T) {
+	if s := errors.New("boom").StatusCode(); s != http.StatusInternalServerError {
+		t.Fatalf("expected the default status to be 500, got %d", s)
+	}
+}
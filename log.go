@@ -0,0 +1,66 @@
+package errors
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// Logger is implemented by the structured loggers errors can be sent to. It
+// decouples this package from any specific logging library: an adapter is
+// usually a few lines long.
+type Logger interface {
+	Log(fields map[string]interface{})
+}
+
+// LoggerFunc allows to use an ordinary function as a Logger.
+type LoggerFunc func(fields map[string]interface{})
+
+// Log calls f(fields).
+func (f LoggerFunc) Log(fields map[string]interface{}) {
+	f(fields)
+}
+
+// StdLogger is a Logger writing the fields, as a single line JSON object, to
+// the standard logger of the log package.
+var StdLogger Logger = LoggerFunc(func(fields map[string]interface{}) {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	log.Print(string(b))
+})
+
+var defaultLogger = struct {
+	sync.RWMutex
+	l Logger
+}{l: StdLogger}
+
+// SetLogger replaces the Logger used by the Log method. It is the StdLogger by
+// default. A nil Logger disables the logging.
+func SetLogger(l Logger) {
+	defaultLogger.Lock()
+	defaultLogger.l = l
+	defaultLogger.Unlock()
+}
+
+// LogTo sends the information of the error and its chain, as returned by
+// Fields, to l. The redaction function registered with SetRedactor, if any, is
+// applied beforehand.
+func (e *Error) LogTo(l Logger) {
+	if e == nil || l == nil {
+		return
+	}
+	c := e.Clone()
+	c.applyRedactor()
+	l.Log(c.Fields())
+}
+
+// Log sends the error to the Logger registered with SetLogger, as LogTo does.
+func (e *Error) Log() {
+	defaultLogger.RLock()
+	l := defaultLogger.l
+	defaultLogger.RUnlock()
+	e.LogTo(l)
+}
@@ -0,0 +1,42 @@
+package errors_test
+
+import (
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+type fakeLogger struct {
+	entries []map[string]interface{}
+}
+
+func (l *fakeLogger) Log(fields map[string]interface{}) {
+	l.entries = append(l.entries, fields)
+}
+
+func TestLogTo(t *testing.T) {
+	l := new(fakeLogger)
+	e := errors.New("query failed").Code(500).AddInfo("table", "users").
+		Wraps(errors.New("timeout").AddInfo("after", "5s"))
+	e.LogTo(l)
+	if len(l.entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(l.entries))
+	}
+	fields := l.entries[0]
+	if fields["ErrorCause"] != "query failed" || fields["ErrorCode"] != "500" || fields["table"] != "users" || fields["after"] != "5s" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+
+	errors.SetLogger(l)
+	defer errors.SetLogger(errors.StdLogger)
+	errors.New("refused").Log()
+	if len(l.entries) != 2 || l.entries[1]["ErrorCause"] != "refused" {
+		t.Fatalf("expected the default logger to be used, got %v", l.entries)
+	}
+
+	errors.SetLogger(nil)
+	errors.New("ignored").Log()
+	if len(l.entries) != 2 {
+		t.Fatal("expected the logging to be disabled")
+	}
+}
@@ -0,0 +1,130 @@
+// Package otelerrors provides information functions correlating errors with
+// OpenTelemetry traces.
+// It lives in its own package so that the errors package does not depend on
+// OpenTelemetry.
+package otelerrors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/atdiar/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the attributes describing an exception, as defined by the
+// OpenTelemetry semantic conventions.
+const (
+	ExceptionTypeKey       = attribute.Key("exception.type")
+	ExceptionMessageKey    = attribute.Key("exception.message")
+	ExceptionStacktraceKey = attribute.Key("exception.stacktrace")
+)
+
+// DefaultExceptionType is the exception type of the errors without a code.
+const DefaultExceptionType = "*errors.Error"
+
+// PrintTraceContext returns the trace and span ids of the span active in ctx,
+// under the "trace_id" and "span_id" keys of a map. The map is empty if there
+// is no valid span context.
+// It is meant to be registered with errors.WithContextInfoFuncs and used with
+// errors.ContextConstructor.
+func PrintTraceContext(ctx context.Context) (fieldname string, ids interface{}) {
+	m := make(map[string]string, 2)
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.IsValid() {
+		m["trace_id"] = sc.TraceID().String()
+		m["span_id"] = sc.SpanID().String()
+	}
+	return "trace_context", m
+}
+
+// Attributes returns the attributes describing e as an exception, following
+// the OpenTelemetry semantic conventions: the code is the exception type, or
+// DefaultExceptionType if there is none, the cause is the exception message
+// and the stack trace recorded with WithStack, or failing that under the
+// "trace" key, is the exception stack trace. The stack trace attribute is
+// omitted if no stack trace was captured.
+func Attributes(e *errors.Error) []attribute.KeyValue {
+	if e == nil {
+		return nil
+	}
+	typ := e.ErrorCode
+	if typ == "" {
+		typ = DefaultExceptionType
+	}
+	attrs := []attribute.KeyValue{
+		ExceptionTypeKey.String(typ),
+		ExceptionMessageKey.String(e.ErrorCause),
+	}
+	if st := stacktrace(e); st != "" {
+		attrs = append(attrs, ExceptionStacktraceKey.String(st))
+	}
+	return attrs
+}
+
+// stacktrace returns the stack trace captured for e, formatted in the manner
+// of a Go panic, if any.
+func stacktrace(e *errors.Error) string {
+	frames := e.Stack()
+	if len(frames) == 0 {
+		v, ok := e.GetInfo("trace")
+		if !ok {
+			return ""
+		}
+		f, ok := v.([]errors.Frame)
+		if !ok {
+			return fmt.Sprint(v)
+		}
+		frames = f
+	}
+	var b strings.Builder
+	for _, f := range frames {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Func, f.File, f.Line)
+	}
+	return b.String()
+}
+
+// OTelCodec is an Error Encoder/Decoder object encoding an error as a JSON
+// object holding the attributes returned by Attributes, e.g. to attach them
+// to a span event recorded by another process.
+// The decoding is best-effort: the exception type is decoded as the code,
+// unless it is DefaultExceptionType, and the message as the cause. The stack
+// trace, if any, is kept under the "trace" key as text.
+var OTelCodec errors.Codec
+
+func init() {
+	OTelCodec = errors.NewCodec(toOTel, fromOTel)
+}
+
+// toOTel encodes an Error as a JSON object of OpenTelemetry attributes.
+func toOTel(i interface{}) ([]byte, error) {
+	e, ok := i.(*errors.Error)
+	if !ok {
+		return json.Marshal(i)
+	}
+	m := make(map[string]string, 3)
+	for _, kv := range Attributes(e) {
+		m[string(kv.Key)] = kv.Value.AsString()
+	}
+	return json.Marshal(m)
+}
+
+// fromOTel decodes a JSON object of OpenTelemetry attributes into an Error. If
+// the object is malformed, the returned Error holds it as its cause.
+func fromOTel(b []byte) *errors.Error {
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return errors.Decoded(OTelCodec, string(b))
+	}
+	e := errors.Decoded(OTelCodec, m[string(ExceptionMessageKey)])
+	if typ := m[string(ExceptionTypeKey)]; typ != DefaultExceptionType {
+		e.CodeString(typ)
+	}
+	if st, ok := m[string(ExceptionStacktraceKey)]; ok {
+		e.AddInfo("trace", st)
+	}
+	return e
+}
@@ -0,0 +1,75 @@
+package otelerrors_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+	"github.com/atdiar/errors/otelerrors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPrintTraceContext(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+		SpanID:     trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	newError := errors.ContextConstructor(errors.JSONCodec, errors.WithContextInfoFuncs(otelerrors.PrintTraceContext))
+	e := newError(ctx, "handler failed")
+
+	ids, ok := e.ErrorInfo["trace_context"].(map[string]string)
+	if !ok {
+		t.Fatalf("expected the trace context to be recorded, got %v", e.ErrorInfo)
+	}
+	if ids["trace_id"] != "0102030405060708090a0b0c0d0e0f10" || ids["span_id"] != "0102030405060708" {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+
+	e = newError(context.Background(), "no span")
+	if ids := e.ErrorInfo["trace_context"].(map[string]string); len(ids) != 0 {
+		t.Fatalf("expected no ids without a span, got %v", ids)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	e := errors.Constructor(errors.JSONCodec)("user not found").Code(404).WithStack()
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range otelerrors.Attributes(e) {
+		attrs[kv.Key] = kv.Value.AsString()
+	}
+	if attrs[otelerrors.ExceptionTypeKey] != "404" || attrs[otelerrors.ExceptionMessageKey] != "user not found" {
+		t.Fatalf("unexpected attributes: %v", attrs)
+	}
+	if !strings.Contains(attrs[otelerrors.ExceptionStacktraceKey], "otelerrors_test.TestAttributes\n\t") {
+		t.Fatalf("expected the stack trace, got %q", attrs[otelerrors.ExceptionStacktraceKey])
+	}
+
+	noStack := otelerrors.Attributes(errors.Constructor(errors.JSONCodec)("failed"))
+	if len(noStack) != 2 || noStack[0].Value.AsString() != otelerrors.DefaultExceptionType {
+		t.Fatalf("unexpected attributes: %v", noStack)
+	}
+}
+
+func TestOTelCodec(t *testing.T) {
+	e := errors.Constructor(otelerrors.OTelCodec)("timeout").CodeString("E_TIMEOUT").WithStack()
+	var m map[string]string
+	if err := json.Unmarshal([]byte(e.Error()), &m); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"exception.type", "exception.message", "exception.stacktrace"} {
+		if m[k] == "" {
+			t.Fatalf("expected the %s key, got %v", k, m)
+		}
+	}
+
+	d := otelerrors.OTelCodec.Decode([]byte(e.Error()))
+	if d.ErrorCause != "timeout" || !d.IsCode("E_TIMEOUT") || !d.HasInfo("trace") {
+		t.Fatalf("unexpected decoded error: %#v", d)
+	}
+}
@@ -0,0 +1,54 @@
+package errors
+
+import (
+	"fmt"
+)
+
+// PanicCode is the symbolic code of the errors created by Recover.
+const PanicCode = "E_PANIC"
+
+// Recover converts a value returned by the built-in recover function into an
+// Error whose cause is the panic message, whose code is PanicCode and which
+// records the stack trace of the panicking goroutine. If the value is an
+// error, it is wrapped, so that it can still be matched by errors.Is.
+// It returns nil if r is nil, i.e. if there was no panic.
+// It is meant to be called from a deferred function:
+//
+//	defer func() {
+//		if e := errors.Recover(recover()); e != nil {
+//			e.Log()
+//		}
+//	}()
+func Recover(r interface{}) *Error {
+	if r == nil {
+		return nil
+	}
+	e := &Error{ErrorCode: PanicCode, codec: JSONCodec}
+	switch v := r.(type) {
+	case *Error:
+		e.ErrorCause = "panic: " + v.ErrorCause
+		e.Underlying = v
+	case error:
+		e.ErrorCause = "panic: " + v.Error()
+		e.underlyingStd = v
+	default:
+		e.ErrorCause = "panic: " + fmt.Sprint(v)
+	}
+	e.ErrorStack = callers()
+	notifyCreate(e)
+	return e
+}
+
+// RecoverInto recovers from a panic, if any, and stores the Error returned by
+// Recover in *err. It must be deferred directly, so that a function can report
+// a panic as an ordinary error:
+//
+//	func handle() (err error) {
+//		defer errors.RecoverInto(&err)
+//		...
+//	}
+func RecoverInto(err *error) {
+	if r := recover(); r != nil {
+		*err = Recover(r)
+	}
+}
@@ -0,0 +1,50 @@
+package errors_test
+
+import (
+	stderrors "errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func panicking(v interface{}) (err error) {
+	defer errors.RecoverInto(&err)
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+	err := panicking("boom")
+	e := errors.As(err)
+	if e == nil || e.ErrorCause != "panic: boom" || !e.IsCode(errors.PanicCode) {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	found := false
+	for _, f := range e.Stack() {
+		if strings.HasSuffix(f.Func, "errors_test.panicking") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the panicking function in the stack, got %v", e.Stack())
+	}
+
+	e = errors.As(panicking(io.ErrUnexpectedEOF))
+	if e.ErrorCause != "panic: unexpected EOF" || !stderrors.Is(e, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected the panic error to be wrapped, got %#v", e)
+	}
+	if len(e.Stack()) == 0 {
+		t.Fatal("expected the stack to be captured")
+	}
+
+	if errors.Recover(nil) != nil {
+		t.Fatal("expected nil without a panic")
+	}
+	if err := func() (err error) {
+		defer errors.RecoverInto(&err)
+		return nil
+	}(); err != nil {
+		t.Fatalf("expected no error without a panic, got %v", err)
+	}
+}
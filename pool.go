@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"sync"
+)
+
+var errorPool = sync.Pool{
+	New: func() interface{} {
+		return new(Error)
+	},
+}
+
+// NewPooled returns an Error obtained from a pool of reusable errors. No
+// information is added to it. It is meant for hot paths creating many
+// short-lived errors. The functions registered with OnCreate are called with
+// it.
+// Once it is not needed anymore, the error should be given back with Release.
+func NewPooled(message string) *Error {
+	e := errorPool.Get().(*Error)
+	e.ErrorCause = message
+	e.codec = JSONCodec
+	notifyCreate(e)
+	return e
+}
+
+// Release resets an error and puts it back in the pool used by NewPooled.
+// A released error must not be retained nor used afterwards, as it may be
+// handed out again by NewPooled.
+func Release(e *Error) {
+	if e == nil {
+		return
+	}
+	for k := range e.ErrorInfo {
+		delete(e.ErrorInfo, k)
+	}
+	e.ErrorCode = ""
+	e.ErrorCause = ""
+	e.ErrorSeverity = 0
+	e.ErrorStack = nil
+	e.Underlying = nil
+	e.ErrorCauses = nil
+	e.causesStd = nil
+	e.underlyingStd = nil
+	e.httpStatus = 0
+	e.detail = ""
+	e.debug = nil
+	e.trace = nil
+	e.cache.cause = ""
+	e.cache.value = ""
+	errorPool.Put(e)
+}
@@ -0,0 +1,37 @@
+package errors_test
+
+import (
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestRelease(t *testing.T) {
+	e := errors.NewPooled("short-lived").Code(500).AddInfo("key", "value")
+	e.Underlying = errors.New("cause")
+	errors.Release(e)
+
+	if len(e.ErrorInfo) != 0 || e.ErrorCode != "" || e.ErrorCause != "" || e.Underlying != nil {
+		t.Fatalf("expected the released error to be reset, got %#v", e)
+	}
+	if e.Source() != nil {
+		t.Fatal("expected the released error not to wrap anything")
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	newError := errors.Constructor(errors.JSONCodec)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e := newError("short-lived").AddInfo("attempt", i)
+		_ = e
+	}
+}
+
+func BenchmarkNewPooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e := errors.NewPooled("short-lived").AddInfo("attempt", i)
+		errors.Release(e)
+	}
+}
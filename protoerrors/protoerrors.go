@@ -0,0 +1,250 @@
+// Package protoerrors provides a codec serializing the Error type of the errors
+// package as a Protocol Buffers message, which gives services written in other
+// languages a stable contract. The schema is described in errors.proto.
+// It lives in its own package so that the errors package does not depend on
+// Protocol Buffers.
+package protoerrors
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/atdiar/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProtoCodec is an Error Encoder/Decoder object using the atdiar.errors.v1.Error
+// message as the serialization format.
+// Information values are sent as text and are therefore decoded as strings.
+// A wrapped error that is not of type Error is encoded as an Error holding its
+// message. The causes merged with Merge or wrapped by WrapsAll are encoded as
+// the branches of the Error holding them.
+var ProtoCodec errors.Codec
+
+func init() {
+	ProtoCodec = errors.NewCodec(toProto, fromProto)
+}
+
+// Field numbers of the atdiar.errors.v1.Error message.
+const (
+	fieldCode     protowire.Number = 1
+	fieldCause    protowire.Number = 2
+	fieldInfo     protowire.Number = 3
+	fieldSeverity protowire.Number = 4
+	fieldCauses   protowire.Number = 5
+	fieldBranches protowire.Number = 6
+
+	fieldKey   protowire.Number = 1
+	fieldValue protowire.Number = 2
+)
+
+// message is the Go equivalent of the atdiar.errors.v1.Error message.
+type message struct {
+	Code     string
+	Cause    string
+	Info     map[string]string
+	Severity int32
+	Causes   []*message
+	Branches []*message
+}
+
+// newTree converts e into a message whose causes are the underlying errors.
+func newTree(e *errors.Error) *message {
+	m := newMessage(e)
+	for err := e.Underlying; err != nil; err = err.Underlying {
+		m.Causes = append(m.Causes, newMessage(err))
+	}
+	if std := e.RootCause().Source(); std != nil {
+		m.Causes = append(m.Causes, &message{Cause: std.Error()})
+	}
+	return m
+}
+
+func newMessage(e *errors.Error) *message {
+	m := &message{
+		Code:     e.ErrorCode,
+		Cause:    e.ErrorCause,
+		Severity: int32(e.ErrorSeverity),
+	}
+	if len(e.ErrorInfo) > 0 {
+		m.Info = make(map[string]string, len(e.ErrorInfo))
+		for k, v := range e.ErrorInfo {
+			if s, ok := v.(string); ok {
+				m.Info[k] = s
+				continue
+			}
+			m.Info[k] = fmt.Sprint(v)
+		}
+	}
+	for _, b := range e.Branches() {
+		if be := errors.As(b); be != nil {
+			m.Branches = append(m.Branches, newTree(be))
+			continue
+		}
+		m.Branches = append(m.Branches, &message{Cause: b.Error()})
+	}
+	return m
+}
+
+// marshal appends the wire encoding of the message to b. The information
+// entries are sorted by key so that the encoding is deterministic.
+func (m *message) marshal(b []byte) []byte {
+	if m.Code != "" {
+		b = protowire.AppendTag(b, fieldCode, protowire.BytesType)
+		b = protowire.AppendString(b, m.Code)
+	}
+	if m.Cause != "" {
+		b = protowire.AppendTag(b, fieldCause, protowire.BytesType)
+		b = protowire.AppendString(b, m.Cause)
+	}
+	keys := make([]string, 0, len(m.Info))
+	for k := range m.Info {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var entry []byte
+		entry = protowire.AppendTag(entry, fieldKey, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, fieldValue, protowire.BytesType)
+		entry = protowire.AppendString(entry, m.Info[k])
+		b = protowire.AppendTag(b, fieldInfo, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	if m.Severity != 0 {
+		b = protowire.AppendTag(b, fieldSeverity, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.Severity))
+	}
+	for _, c := range m.Causes {
+		b = protowire.AppendTag(b, fieldCauses, protowire.BytesType)
+		b = protowire.AppendBytes(b, c.marshal(nil))
+	}
+	for _, c := range m.Branches {
+		b = protowire.AppendTag(b, fieldBranches, protowire.BytesType)
+		b = protowire.AppendBytes(b, c.marshal(nil))
+	}
+	return b
+}
+
+// unmarshal decodes the wire encoding of a message. Unknown fields are
+// skipped, as required for the schema to evolve.
+func (m *message) unmarshal(b []byte) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == fieldCode && typ == protowire.BytesType:
+			m.Code, n = protowire.ConsumeString(b)
+		case num == fieldCause && typ == protowire.BytesType:
+			m.Cause, n = protowire.ConsumeString(b)
+		case num == fieldInfo && typ == protowire.BytesType:
+			var entry []byte
+			entry, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				if err := m.unmarshalEntry(entry); err != nil {
+					return err
+				}
+			}
+		case num == fieldSeverity && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			m.Severity = int32(v)
+		case (num == fieldCauses || num == fieldBranches) && typ == protowire.BytesType:
+			var data []byte
+			data, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				c := new(message)
+				if err := c.unmarshal(data); err != nil {
+					return err
+				}
+				if num == fieldCauses {
+					m.Causes = append(m.Causes, c)
+				} else {
+					m.Branches = append(m.Branches, c)
+				}
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// unmarshalEntry decodes an entry of the info map.
+func (m *message) unmarshalEntry(b []byte) error {
+	var key, value string
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == fieldKey && typ == protowire.BytesType:
+			key, n = protowire.ConsumeString(b)
+		case num == fieldValue && typ == protowire.BytesType:
+			value, n = protowire.ConsumeString(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	if m.Info == nil {
+		m.Info = make(map[string]string)
+	}
+	m.Info[key] = value
+	return nil
+}
+
+func (m *message) toError() *errors.Error {
+	e := errors.Decoded(ProtoCodec, m.Cause).CodeString(m.Code).Severity(errors.Severity(m.Severity))
+	for k, v := range m.Info {
+		e.AddInfo(k, v)
+	}
+	for _, b := range m.Branches {
+		e.ErrorCauses = append(e.ErrorCauses, b.toTree())
+	}
+	return e
+}
+
+// toTree converts a message into an Error whose chain is made of the causes.
+func (m *message) toTree() *errors.Error {
+	e := m.toError()
+	last := e
+	for _, c := range m.Causes {
+		last.Underlying = c.toError()
+		last = last.Underlying
+	}
+	return e
+}
+
+// toProto encodes an Error as an atdiar.errors.v1.Error message. The chain is
+// cloned beforehand, so that a cyclic chain is encoded up to the first
+// repeated Error.
+func toProto(i interface{}) ([]byte, error) {
+	e, ok := i.(*errors.Error)
+	if !ok || e == nil {
+		return nil, fmt.Errorf("protoerrors: unable to encode %T", i)
+	}
+	return newTree(e.Clone()).marshal(nil), nil
+}
+
+// fromProto decodes an atdiar.errors.v1.Error message into an Error. If the
+// message is malformed, the returned Error holds the failure as its cause.
+func fromProto(b []byte) *errors.Error {
+	var m message
+	if err := m.unmarshal(b); err != nil {
+		return errors.Decoded(ProtoCodec, "protoerrors: unable to decode: "+err.Error())
+	}
+	return m.toTree()
+}
@@ -0,0 +1,81 @@
+package protoerrors_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+	"github.com/atdiar/errors/protoerrors"
+)
+
+func TestRoundTrip(t *testing.T) {
+	newError := errors.Constructor(protoerrors.ProtoCodec)
+	root := newError("timeout").CodeString("E_TIMEOUT").AddInfo("after", "5s").Wraps(io.EOF)
+	mid := newError("query failed").Code(500).Severity(errors.SeverityFatal).AddInfo("rows", 12).Wraps(root)
+	e := newError("checkout failed").AddInfo("user", "alice").AddInfo("retry", true).Wraps(mid)
+
+	b, err := protoerrors.ProtoCodec.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := protoerrors.ProtoCodec.Decode(b)
+	if d.Depth() != 4 {
+		t.Fatalf("expected a chain of 4 errors, got %d", d.Depth())
+	}
+	if d.ErrorCause != "checkout failed" || d.ErrorInfo["user"] != "alice" || d.ErrorInfo["retry"] != "true" {
+		t.Fatalf("unexpected error: %#v", d)
+	}
+	m := d.Underlying
+	if m.ErrorCause != "query failed" || !m.HasCode(500) || m.GetSeverity() != errors.SeverityFatal || m.ErrorInfo["rows"] != "12" {
+		t.Fatalf("unexpected underlying error: %#v", m)
+	}
+	r := m.Underlying
+	if r.ErrorCause != "timeout" || !r.IsCode("E_TIMEOUT") || r.ErrorInfo["after"] != "5s" {
+		t.Fatalf("unexpected root error: %#v", r)
+	}
+	if r.Underlying.ErrorCause != "EOF" {
+		t.Fatalf("expected the standard error to be encoded as an Error, got %#v", r.Underlying)
+	}
+
+	again, err := protoerrors.ProtoCodec.Encode(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(b) {
+		t.Fatal("expected the encoding to be deterministic")
+	}
+	if d.Error() != string(b) {
+		t.Fatal("expected the decoded error to use the ProtoCodec")
+	}
+
+	merged := newError("primary failed").Wraps(root).Merge(newError("fallback failed").Code(504))
+	b, err = protoerrors.ProtoCodec.Encode(newError("sync failed").Wraps(merged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = protoerrors.ProtoCodec.Decode(b)
+	if c := d.Underlying.Causes(); len(c) != 2 || c[0].Underlying.ErrorCause != "timeout" || !c[1].HasCode(504) {
+		t.Fatalf("expected the merged causes to survive the round trip, got %v", d.Underlying.Causes())
+	}
+
+	if d := protoerrors.ProtoCodec.Decode([]byte{0x0a, 0x05, 'a'}); !strings.HasPrefix(d.ErrorCause, "protoerrors: unable to decode") {
+		t.Fatalf("expected a truncated message to be reported, got %q", d.ErrorCause)
+	}
+}
+
+func TestDecodeWithoutHooks(t *testing.T) {
+	e := errors.Constructor(protoerrors.ProtoCodec)("query failed").Wraps(errors.New("timeout"))
+	b, err := protoerrors.ProtoCodec.Encode(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created int
+	unregister := errors.OnCreate(func(*errors.Error) { created++ })
+	defer unregister()
+	protoerrors.ProtoCodec.Decode(b)
+	protoerrors.ProtoCodec.Decode([]byte{0xff})
+	if created != 0 {
+		t.Fatalf("expected decoding not to be reported as the creation of errors, got %d", created)
+	}
+}
@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"sync"
+)
+
+// Redacted is the value replacing sensitive information in a redacted error.
+const Redacted = "[REDACTED]"
+
+// Redact returns a copy of the error in which the information stored under
+// any of the given keys is masked, at every level of the chain and of the
+// merged causes.
+// It should be used before sending an error to an untrusted party.
+func (e *Error) Redact(keys ...string) *Error {
+	c := e.Clone()
+	c.walkTree(func(err *Error) {
+		for _, k := range keys {
+			if _, ok := err.ErrorInfo[k]; ok {
+				err.ErrorInfo[k] = Redacted
+			}
+		}
+	})
+	return c
+}
+
+// Sanitized returns a codec that encodes errors with c after dropping, at every
+// level of the chain and of the merged causes, the information stored under keys that are not allowed.
+// It ensures that internal information is not sent to an untrusted party by
+// accident. The details set with Detail are always dropped, whatever the keys
+// allowed. Decoding is left to c.
+func Sanitized(c Codec, allow ...string) Codec {
+	allowed := make(map[string]bool, len(allow))
+	for _, k := range allow {
+		allowed[k] = true
+	}
+	return NewCodec(func(i interface{}) ([]byte, error) {
+		e, ok := i.(*Error)
+		if !ok || e == nil {
+			return c.Encode(i)
+		}
+		s := e.Clone()
+		s.walkTree(func(err *Error) {
+			for k := range err.ErrorInfo {
+				if !allowed[k] || k == detailKey {
+					delete(err.ErrorInfo, k)
+				}
+			}
+		})
+		return c.Encode(s)
+	}, c.Decode)
+}
+
+var redactor struct {
+	sync.RWMutex
+	fn func(key string, value interface{}) (interface{}, bool)
+}
+
+// SetRedactor registers a function that is consulted for every information
+// entry, at every level of the chain, each time an error is serialized by its
+// Error method. It returns the value to serialize in place of the original one,
+// or false if the entry should be dropped altogether.
+// It is typically called once at startup in order to enforce a deny-list of
+// keys. A nil function disables the redaction.
+func SetRedactor(fn func(key string, value interface{}) (interface{}, bool)) {
+	redactor.Lock()
+	redactor.fn = fn
+	redactor.Unlock()
+}
+
+// redacting reports whether a redaction function is registered.
+func redacting() bool {
+	redactor.RLock()
+	defer redactor.RUnlock()
+	return redactor.fn != nil
+}
+
+// applyRedactor applies the registered redaction function to the information
+// of the error, its chain and its merged causes, in place.
+func (e *Error) applyRedactor() {
+	redactor.RLock()
+	fn := redactor.fn
+	redactor.RUnlock()
+	if fn == nil {
+		return
+	}
+	e.walkTree(func(err *Error) {
+		for k, v := range err.ErrorInfo {
+			if nv, ok := fn(k, v); ok {
+				err.ErrorInfo[k] = nv
+			} else {
+				delete(err.ErrorInfo, k)
+			}
+		}
+	})
+}
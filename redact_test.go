@@ -0,0 +1,113 @@
+package errors_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestRedact(t *testing.T) {
+	cause := errors.New("authentication failed").AddInfo("password", "hunter2")
+	e := errors.New("login").AddInfo("token", "s3cr3t").AddInfo("user", "bob").Wraps(cause)
+
+	r := e.Redact("password", "token")
+	s := r.Error()
+	if strings.Contains(s, "hunter2") || strings.Contains(s, "s3cr3t") {
+		t.Fatalf("expected the secrets to be masked, got %s", s)
+	}
+	if r.ErrorInfo["token"] != errors.Redacted || r.Underlying.ErrorInfo["password"] != errors.Redacted {
+		t.Fatal("expected the secrets to be masked at every level")
+	}
+	if r.ErrorInfo["user"] != "bob" {
+		t.Fatal("expected the other information to be kept")
+	}
+	if e.ErrorInfo["token"] != "s3cr3t" || cause.ErrorInfo["password"] != "hunter2" {
+		t.Fatal("expected the original errors to be left untouched")
+	}
+}
+
+func TestSetRedactor(t *testing.T) {
+	errors.SetRedactor(func(key string, value interface{}) (interface{}, bool) {
+		switch key {
+		case "password":
+			return nil, false
+		case "token":
+			return errors.Redacted, true
+		}
+		return value, true
+	})
+	defer errors.SetRedactor(nil)
+
+	cause := errors.New("authentication failed").AddInfo("password", "hunter2")
+	e := errors.New("login").AddInfo("token", "s3cr3t").AddInfo("user", "bob").Wraps(cause)
+
+	s := e.Error()
+	if strings.Contains(s, "hunter2") || strings.Contains(s, "password") {
+		t.Fatalf("expected the password to be dropped, got %s", s)
+	}
+	if strings.Contains(s, "s3cr3t") || !strings.Contains(s, errors.Redacted) {
+		t.Fatalf("expected the token to be masked, got %s", s)
+	}
+	if !strings.Contains(s, "bob") {
+		t.Fatalf("expected the other information to be kept, got %s", s)
+	}
+	if cause.ErrorInfo["password"] != "hunter2" {
+		t.Fatal("expected the original error to be left untouched")
+	}
+}
+
+func TestSanitized(t *testing.T) {
+	codec := errors.Sanitized(errors.JSONCodec, "request_id")
+	cause := errors.New("query failed").AddInfo("sql", "SELECT * FROM users").AddInfo("request_id", "req-42")
+	e := errors.Constructor(codec)("internal error").AddInfo("request_id", "req-42").AddInfo("host", "db1").Wraps(cause)
+
+	s := e.Error()
+	if strings.Contains(s, "SELECT") || strings.Contains(s, "db1") {
+		t.Fatalf("expected the disallowed information to be dropped, got %s", s)
+	}
+	if strings.Count(s, "req-42") != 2 {
+		t.Fatalf("expected the allowed information to be kept at every level, got %s", s)
+	}
+	if !e.HasInfo("host") || !cause.HasInfo("sql") {
+		t.Fatal("expected the original errors to be left untouched")
+	}
+
+	d := codec.Decode([]byte(s))
+	if v, _ := d.GetString("request_id"); v != "req-42" || d.Underlying == nil {
+		t.Fatalf("expected the payload to be decoded as is, got %#v", d)
+	}
+}
+
+func TestDetail(t *testing.T) {
+	e := errors.Constructor(errors.JSONCodec)("service unavailable").Detail("dial tcp 10.0.0.7:5432: connection refused")
+	if e.GetDetail() != "dial tcp 10.0.0.7:5432: connection refused" {
+		t.Fatalf("unexpected detail: %q", e.GetDetail())
+	}
+	if strings.Contains(e.Error(), "10.0.0.7") {
+		t.Fatalf("expected the detail to be omitted without debugging, got %s", e.Error())
+	}
+	if !strings.Contains(fmt.Sprintf("%+v", e), "detail: dial tcp") {
+		t.Fatal("expected the detail in the verbose output")
+	}
+
+	e.Debug(true)
+	d := errors.JSONCodec.Decode([]byte(strings.SplitN(e.Error(), "\n\nTRACE", 2)[0]))
+	if d.GetDetail() != e.GetDetail() || d.ErrorCause != "service unavailable" {
+		t.Fatalf("expected the detail to be serialized when debugging, got %#v", d)
+	}
+
+	u := errors.Constructor(errors.JSONCodec)("service unavailable").AddInfo("detail", "retry later").
+		Detail("dial tcp 10.0.0.7:5432: connection refused").Debug(true)
+	d = errors.JSONCodec.Decode([]byte(strings.SplitN(u.Error(), "\n\nTRACE", 2)[0]))
+	if v, _ := d.GetString("detail"); v != "retry later" || d.GetDetail() != u.GetDetail() {
+		t.Fatalf("expected the detail not to overwrite the information of the user, got %v", d.ErrorInfo)
+	}
+
+	s := errors.Constructor(errors.Sanitized(errors.JSONCodec, "detail", "ErrorDetail"))("service unavailable").
+		AddInfo("detail", "retry later").Detail("dial tcp 10.0.0.7:5432: connection refused").Debug(true)
+	if out := s.Error(); strings.Contains(out, "10.0.0.7") || !strings.Contains(out, "retry later") {
+		t.Fatalf("expected only the detail to be dropped by the sanitized codec, got %s", out)
+	}
+}
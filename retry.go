@@ -0,0 +1,162 @@
+package errors
+
+import (
+	"strconv"
+	"time"
+)
+
+// SetTemporary marks the error as temporary or not. The flag is stored as
+// information under the "temporary" key.
+func (e *Error) SetTemporary(temporary bool) *Error {
+	return e.AddInfo("temporary", temporary)
+}
+
+// SetTimeout marks the error as resulting from a timeout or not. The flag is
+// stored as information under the "timeout" key.
+func (e *Error) SetTimeout(timeout bool) *Error {
+	return e.AddInfo("timeout", timeout)
+}
+
+// Temporary reports whether the error is temporary, which matches the shape of
+// the net.Error interface.
+// If the flag was not set on the error, it is looked up along the chain of
+// underlying errors.
+func (e *Error) Temporary() bool {
+	return e.chainFlag("temporary", func(err error) (bool, bool) {
+		t, ok := err.(interface{ Temporary() bool })
+		if !ok {
+			return false, false
+		}
+		return t.Temporary(), true
+	})
+}
+
+// Timeout reports whether the error results from a timeout, which matches the
+// shape of the net.Error interface.
+// If the flag was not set on the error, it is looked up along the chain of
+// underlying errors.
+func (e *Error) Timeout() bool {
+	return e.chainFlag("timeout", func(err error) (bool, bool) {
+		t, ok := err.(interface{ Timeout() bool })
+		if !ok {
+			return false, false
+		}
+		return t.Timeout(), true
+	})
+}
+
+// chainFlag returns the value of the first boolean flag stored under key along
+// the chain. If none is found, the wrapped error that is not of type Error, if
+// any, is queried via std.
+func (e *Error) chainFlag(key string, std func(error) (bool, bool)) bool {
+	var flag bool
+	e.Walk(func(err *Error) bool {
+		if v, ok := err.infoBool(key); ok {
+			flag = v
+			return false
+		}
+		if err.Underlying == nil && err.underlyingStd != nil {
+			flag, _ = std(err.underlyingStd)
+		}
+		return true
+	})
+	return flag
+}
+
+// infoBool returns the boolean stored as information under key. Booleans
+// decoded as text, e.g. by the XMLCodec, are parsed.
+func (e *Error) infoBool(key string) (bool, bool) {
+	e.mu.RLock()
+	v, ok := e.ErrorInfo[key]
+	e.mu.RUnlock()
+	if !ok {
+		return false, false
+	}
+	return toBool(v)
+}
+
+// toBool converts an information value to a boolean.
+func toBool(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
+
+// SetRetryable explicitly marks the error as retryable or not. The flag is
+// stored as information under the "retryable" key.
+func (e *Error) SetRetryable(retryable bool) *Error {
+	return e.AddInfo("retryable", retryable)
+}
+
+// Retryable reports whether the operation that failed may be retried.
+// If the error was not explicitly marked with SetRetryable, along its chain,
+// it is considered retryable when it is temporary, results from a timeout or
+// holds a retry delay.
+func (e *Error) Retryable() bool {
+	retryable, found := false, false
+	e.Walk(func(err *Error) bool {
+		retryable, found = err.infoBool("retryable")
+		return !found
+	})
+	if found {
+		return retryable
+	}
+	if _, ok := e.GetRetryAfter(); ok {
+		return true
+	}
+	return e.Temporary() || e.Timeout()
+}
+
+// RetryAfter records the delay after which the failed operation may be
+// retried. It is stored as information under the "retry_after_ms" key, as a
+// number of milliseconds.
+func (e *Error) RetryAfter(d time.Duration) *Error {
+	return e.AddInfo("retry_after_ms", d.Milliseconds())
+}
+
+// GetRetryAfter returns the delay recorded by RetryAfter, looking it up along
+// the chain of underlying errors.
+func (e *Error) GetRetryAfter() (time.Duration, bool) {
+	var ms int64
+	found := false
+	e.Walk(func(err *Error) bool {
+		ms, found = err.infoInt("retry_after_ms")
+		return !found
+	})
+	return time.Duration(ms) * time.Millisecond, found
+}
+
+// infoInt returns the integer stored as information under key. Numbers decoded
+// as floating point numbers, e.g. by the JSONCodec, or as text are converted.
+func (e *Error) infoInt(key string) (int64, bool) {
+	e.mu.RLock()
+	v, ok := e.ErrorInfo[key]
+	e.mu.RUnlock()
+	if !ok {
+		return 0, false
+	}
+	return toInt(v)
+}
+
+// toInt converts an information value to an integer.
+func toInt(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,89 @@
+package errors_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/atdiar/errors"
+)
+
+func TestTemporaryTimeout(t *testing.T) {
+	e := errors.New("dial failed").SetTemporary(true).SetTimeout(true)
+	if !e.Temporary() || !e.Timeout() {
+		t.Fatal("expected the flags to be set")
+	}
+	var _ interface {
+		Temporary() bool
+		Timeout() bool
+	} = e
+
+	w := errors.New("fetching profile").Wraps(errors.New("request").Wraps(e))
+	if !w.Temporary() || !w.Timeout() {
+		t.Fatal("expected a wrapping error to expose the flags of its cause")
+	}
+	if w.SetTemporary(false).Temporary() {
+		t.Fatal("expected the flag of the outer error to take precedence")
+	}
+	if errors.New("plain").Temporary() {
+		t.Fatal("expected an error not to be temporary by default")
+	}
+
+	std := &net.DNSError{Err: "no such host", IsTimeout: true}
+	if !errors.New("resolving").Wraps(std).Timeout() {
+		t.Fatal("expected the flag of a wrapped standard error to be exposed")
+	}
+}
+
+func TestTemporaryRoundTrip(t *testing.T) {
+	for name, codec := range map[string]errors.Codec{
+		"json": errors.JSONCodec,
+		"xml":  errors.XMLCodec,
+		"gob":  errors.GobCodec,
+	} {
+		e := errors.Constructor(codec)("dial failed").SetTemporary(true).SetTimeout(false)
+		d := codec.Decode([]byte(e.Error()))
+		if !d.Temporary() || d.Timeout() {
+			t.Fatalf("%s: expected the flags to survive a round-trip", name)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	e := errors.New("rate limited").RetryAfter(1500 * time.Millisecond)
+	d, ok := e.GetRetryAfter()
+	if !ok || d != 1500*time.Millisecond {
+		t.Fatalf("expected a delay of 1.5s, got %v (%v)", d, ok)
+	}
+	if !e.Retryable() {
+		t.Fatal("expected an error with a retry delay to be retryable")
+	}
+	if e.ErrorInfo["retry_after_ms"] != int64(1500) {
+		t.Fatalf("expected the delay to be stored in milliseconds, got %v", e.ErrorInfo["retry_after_ms"])
+	}
+
+	w := errors.New("calling api").Wraps(e)
+	decoded := errors.JSONCodec.Decode([]byte(w.Error()))
+	if d, ok := decoded.GetRetryAfter(); !ok || d != 1500*time.Millisecond {
+		t.Fatalf("expected the delay to survive a JSON round-trip, got %v (%v)", d, ok)
+	}
+
+	if _, ok := errors.New("plain").GetRetryAfter(); ok {
+		t.Fatal("expected no delay by default")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	if errors.New("plain").Retryable() {
+		t.Fatal("expected an error not to be retryable by default")
+	}
+	if !errors.New("flaky").SetTemporary(true).Retryable() {
+		t.Fatal("expected a temporary error to be retryable")
+	}
+	if errors.New("flaky").SetTemporary(true).SetRetryable(false).Retryable() {
+		t.Fatal("expected the explicit flag to take precedence")
+	}
+	if !errors.New("wrapper").Wraps(errors.New("cause").SetRetryable(true)).Retryable() {
+		t.Fatal("expected the explicit flag to be looked up along the chain")
+	}
+}
@@ -0,0 +1,120 @@
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity defines the level of gravity of an error. It can be used to route
+// errors to the appropriate logs.
+type Severity int
+
+// List of the severity levels, from the least to the most severe.
+// The zero value means that no severity was set.
+const (
+	SeverityDebug Severity = iota + 1
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+var severityNames = map[Severity]string{
+	SeverityDebug: "debug",
+	SeverityInfo:  "info",
+	SeverityWarn:  "warn",
+	SeverityError: "error",
+	SeverityFatal: "fatal",
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText allows a Severity to be serialized by its name. A level that
+// has no name is serialized as returned by String, e.g. "Severity(9)", so that
+// an error holding it can still be serialized.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText retrieves a Severity from its name, or from the form used by
+// MarshalText for the levels that have no name.
+func (s *Severity) UnmarshalText(b []byte) error {
+	for level, name := range severityNames {
+		if name == string(b) {
+			*s = level
+			return nil
+		}
+	}
+	if t := string(b); strings.HasPrefix(t, "Severity(") && strings.HasSuffix(t, ")") {
+		if level, err := strconv.Atoi(t[len("Severity(") : len(t)-1]); err == nil {
+			*s = Severity(level)
+			return nil
+		}
+	}
+	return fmt.Errorf("errors: unknown severity %q", string(b))
+}
+
+// Severity sets the severity level of an error.
+func (e *Error) Severity(level Severity) *Error {
+	e.ErrorSeverity = level
+	return e
+}
+
+// GetSeverity returns the severity level of an error. An error for which no
+// severity was set is considered to be at the SeverityError level.
+func (e *Error) GetSeverity() Severity {
+	if e == nil || e.ErrorSeverity == 0 {
+		return SeverityError
+	}
+	return e.ErrorSeverity
+}
+
+// RenderAbove returns the serialization of the list, as returned by its Error
+// method, restricted to the values whose severity is at or above level.
+// Values that are not of type Error are considered to be at the SeverityError
+// level.
+func (l *List) RenderAbove(level Severity) string {
+	filtered := NewList()
+	for _, v := range l.values() {
+		s := SeverityError
+		if e, ok := v.(*Error); ok {
+			s = e.GetSeverity()
+		}
+		if s >= level {
+			filtered.Values = append(filtered.Values, v)
+		}
+	}
+	return filtered.Error()
+}
+
+// Sort orders the values of the list so that it renders predictably: the
+// Errors come first, from the most severe to the least severe, as returned by
+// GetSeverity, then by code. Numeric codes are compared as numbers, other codes
+// as strings. Values that are not of type Error come last.
+// The order of equivalent values is preserved.
+func (l *List) Sort() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sort.SliceStable(l.Values, func(i, j int) bool {
+		a, b := As(l.Values[i]), As(l.Values[j])
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		if sa, sb := a.GetSeverity(), b.GetSeverity(); sa != sb {
+			return sa > sb
+		}
+		ca, oka := a.GetCode()
+		cb, okb := b.GetCode()
+		if oka && okb {
+			return ca < cb
+		}
+		return a.ErrorCode < b.ErrorCode
+	})
+}
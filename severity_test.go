@@ -0,0 +1,107 @@
+package errors_test
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestSeverity(t *testing.T) {
+	cause := errors.New("disk almost full").Severity(errors.SeverityWarn)
+	e := errors.New("writing cache").Wraps(cause)
+
+	if e.GetSeverity() != errors.SeverityError {
+		t.Fatalf("expected the default severity, got %s", e.GetSeverity())
+	}
+	if cause.GetSeverity() != errors.SeverityWarn {
+		t.Fatalf("expected the cause to keep its own severity, got %s", cause.GetSeverity())
+	}
+
+	e.Severity(errors.SeverityFatal)
+	if e.GetSeverity() != errors.SeverityFatal || cause.GetSeverity() != errors.SeverityWarn {
+		t.Fatal("expected the severities of the chain to be independent")
+	}
+
+	s := e.Error()
+	if !strings.Contains(s, `"ErrorSeverity": "fatal"`) || !strings.Contains(s, `"ErrorSeverity": "warn"`) {
+		t.Fatalf("expected the severities to be serialized by name, got %s", s)
+	}
+
+	d := errors.JSONCodec.Decode([]byte(s))
+	if d.GetSeverity() != errors.SeverityFatal || d.Underlying.GetSeverity() != errors.SeverityWarn {
+		t.Fatal("expected the severities to survive decoding")
+	}
+
+	u := errors.Constructor(errors.JSONCodec)("unknown level").Severity(9)
+	s = u.Error()
+	if !strings.Contains(s, `"ErrorSeverity": "Severity(9)"`) {
+		t.Fatalf("expected an unknown severity to be serialized numerically, got %s", s)
+	}
+	if d := errors.JSONCodec.Decode([]byte(s)); d.ErrorSeverity != 9 {
+		t.Fatalf("expected an unknown severity to survive decoding, got %v", d.ErrorSeverity)
+	}
+}
+
+func TestListRenderAbove(t *testing.T) {
+	l := errors.NewList()
+	l.Add(
+		errors.New("cache miss").Severity(errors.SeverityDebug),
+		errors.New("disk almost full").Severity(errors.SeverityWarn),
+		stderrors.New("connection refused"),
+		errors.New("corrupted index").Severity(errors.SeverityFatal),
+	)
+
+	s := l.RenderAbove(errors.SeverityWarn)
+	if strings.Contains(s, "cache miss") {
+		t.Fatalf("expected the debug error to be filtered out, got %s", s)
+	}
+	for _, msg := range []string{"disk almost full", "connection refused", "corrupted index"} {
+		if !strings.Contains(s, msg) {
+			t.Fatalf("expected %q to be rendered, got %s", msg, s)
+		}
+	}
+
+	s = l.RenderAbove(errors.SeverityFatal)
+	if !strings.Contains(s, "corrupted index") || strings.Contains(s, "connection refused") {
+		t.Fatalf("expected only the fatal error to be rendered, got %s", s)
+	}
+	if l.Len() != 4 {
+		t.Fatal("expected the list to be left untouched")
+	}
+}
+
+func TestListSort(t *testing.T) {
+	newError := errors.Constructor(errors.JSONCodec)
+	std := stderrors.New("plain")
+	l := errors.NewList()
+	l.Add(
+		newError("warn 200").Code(200).Severity(errors.SeverityWarn),
+		std,
+		newError("error 1000").Code(1000),
+		newError("fatal").Code(500).Severity(errors.SeverityFatal),
+		nil,
+		newError("error 404").Code(404),
+		newError("debug").Severity(errors.SeverityDebug),
+		newError("warn 100").Code(100).Severity(errors.SeverityWarn),
+	)
+	l.Sort()
+
+	var got []string
+	for _, v := range l.Values {
+		if v == nil {
+			got = append(got, "<nil>")
+			continue
+		}
+		if e := errors.As(v); e != nil {
+			got = append(got, e.ErrorCause)
+			continue
+		}
+		got = append(got, v.Error())
+	}
+	want := "fatal,error 404,error 1000,warn 100,warn 200,debug,plain,<nil>"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("expected %s, got %s", want, strings.Join(got, ","))
+	}
+}
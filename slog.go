@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// LogValue implements the slog.LogValuer interface, so that an Error logged
+// with the log/slog package is recorded as structured attributes rather than as
+// its JSON serialization. The attributes are named after the JSON fields: the
+// information is grouped under "ErrorInfo", the underlying error, if any,
+// under "ErrorSource" and the branches, if any, under "ErrorCauses", keyed by
+// their index, recursively.
+// The redaction function registered with SetRedactor, if any, is applied.
+func (e *Error) LogValue() slog.Value {
+	if e == nil {
+		return slog.StringValue("<nil>")
+	}
+	c := e.Clone()
+	c.applyRedactor()
+	return c.logValue()
+}
+
+// logValue returns the slog representation of an error that is not shared.
+func (e *Error) logValue() slog.Value {
+	attrs := make([]slog.Attr, 0, 5)
+	if e.ErrorCode != "" {
+		attrs = append(attrs, slog.String("ErrorCode", e.ErrorCode))
+	}
+	attrs = append(attrs, slog.String("ErrorCause", e.ErrorCause))
+	if e.ErrorSeverity != 0 {
+		attrs = append(attrs, slog.String("ErrorSeverity", e.ErrorSeverity.String()))
+	}
+	if len(e.ErrorInfo) > 0 {
+		keys := make([]string, 0, len(e.ErrorInfo))
+		for k := range e.ErrorInfo {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		info := make([]slog.Attr, 0, len(keys))
+		for _, k := range keys {
+			info = append(info, slog.Any(k, e.ErrorInfo[k]))
+		}
+		attrs = append(attrs, slog.Attr{Key: "ErrorInfo", Value: slog.GroupValue(info...)})
+	}
+	switch {
+	case e.Underlying != nil:
+		attrs = append(attrs, slog.Attr{Key: "ErrorSource", Value: e.Underlying.logValue()})
+	case e.underlyingStd != nil:
+		attrs = append(attrs, slog.Group("ErrorSource", slog.String("ErrorCause", e.underlyingStd.Error())))
+	}
+	if causes := e.allCauses(); len(causes) > 0 {
+		branches := make([]slog.Attr, 0, len(causes))
+		for i, c := range causes {
+			branches = append(branches, slog.Attr{Key: strconv.Itoa(i), Value: c.logValue()})
+		}
+		attrs = append(attrs, slog.Attr{Key: "ErrorCauses", Value: slog.GroupValue(branches...)})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Attrs returns the information of the error as a flat list of slog
+// attributes, for the handlers that do not resolve an slog.LogValuer. As with
+// Fields, the code and cause are recorded under the "ErrorCode" and
+// "ErrorCause" keys, but the information of the underlying error, if any, is
+// grouped under the "cause" key, and that of the branches, if any, under the
+// "causes" key, keyed by their index, recursively.
+// Integers, booleans, durations and strings are recorded with their own kind,
+// as is the date stored under the "date" key. The redaction function
+// registered with SetRedactor, if any, is applied.
+func (e *Error) Attrs() []slog.Attr {
+	if e == nil {
+		return nil
+	}
+	c := e.Clone()
+	c.applyRedactor()
+	return c.attrs()
+}
+
+// attrs returns the slog attributes of an error that is not shared.
+func (e *Error) attrs() []slog.Attr {
+	keys := make([]string, 0, len(e.ErrorInfo))
+	for k := range e.ErrorInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys)+3)
+	for _, k := range keys {
+		attrs = append(attrs, toAttr(k, e.ErrorInfo[k]))
+	}
+	if e.ErrorCode != "" {
+		attrs = append(attrs, slog.String("ErrorCode", e.ErrorCode))
+	}
+	attrs = append(attrs, slog.String("ErrorCause", e.ErrorCause))
+	switch {
+	case e.Underlying != nil:
+		attrs = append(attrs, slog.Attr{Key: "cause", Value: slog.GroupValue(e.Underlying.attrs()...)})
+	case e.underlyingStd != nil:
+		attrs = append(attrs, slog.Group("cause", slog.String("ErrorCause", e.underlyingStd.Error())))
+	}
+	if causes := e.allCauses(); len(causes) > 0 {
+		branches := make([]slog.Attr, 0, len(causes))
+		for i, c := range causes {
+			branches = append(branches, slog.Attr{Key: strconv.Itoa(i), Value: slog.GroupValue(c.attrs()...)})
+		}
+		attrs = append(attrs, slog.Attr{Key: "causes", Value: slog.GroupValue(branches...)})
+	}
+	return attrs
+}
+
+// toAttr returns an slog attribute of the kind matching the information value.
+func toAttr(key string, v interface{}) slog.Attr {
+	if key == "date" {
+		if t, ok := toTime(v); ok {
+			return slog.Time(key, t)
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return slog.String(key, v)
+	case bool:
+		return slog.Bool(key, v)
+	case time.Time:
+		return slog.Time(key, v)
+	case time.Duration:
+		return slog.Duration(key, v)
+	case float64:
+		if i, ok := toInt(v); ok {
+			return slog.Int64(key, i)
+		}
+		return slog.Float64(key, v)
+	}
+	if i, ok := toInt(v); ok {
+		return slog.Int64(key, i)
+	}
+	return slog.Any(key, v)
+}
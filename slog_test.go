@@ -0,0 +1,141 @@
+package errors_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atdiar/errors"
+)
+
+// captureHandler records the attributes of the logged records, flattened into
+// dot-separated keys.
+type captureHandler struct {
+	attrs map[string]slog.Value
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		h.add("", a)
+		return true
+	})
+	return nil
+}
+
+func (h *captureHandler) add(prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			h.add(prefix+a.Key+".", ga)
+		}
+		return
+	}
+	h.attrs[prefix+a.Key] = v
+}
+
+func TestLogValue(t *testing.T) {
+	h := &captureHandler{attrs: make(map[string]slog.Value)}
+	e := errors.Constructor(errors.JSONCodec)("query failed").Code(500).AddInfo("table", "users").
+		Wraps(errors.Constructor(errors.JSONCodec)("timeout").AddInfo("after", 5).Wraps(io.EOF))
+	slog.New(h).Error("request failed", "err", e)
+
+	want := map[string]string{
+		"err.ErrorCode":                          "500",
+		"err.ErrorCause":                         "query failed",
+		"err.ErrorInfo.table":                    "users",
+		"err.ErrorSource.ErrorCause":             "timeout",
+		"err.ErrorSource.ErrorInfo.after":        "5",
+		"err.ErrorSource.ErrorSource.ErrorCause": "EOF",
+	}
+	for k, v := range want {
+		if got, ok := h.attrs[k]; !ok || got.String() != v {
+			t.Fatalf("expected %s=%s, got %v", k, v, h.attrs)
+		}
+	}
+	if len(h.attrs) != len(want) {
+		t.Fatalf("unexpected attributes: %v", h.attrs)
+	}
+
+	h = &captureHandler{attrs: make(map[string]slog.Value)}
+	newError := errors.Constructor(errors.JSONCodec)
+	m := newError("primary").Merge(newError("fallback").WrapsAll(newError("dns"), io.EOF))
+	slog.New(h).Error("request failed", "err", m)
+
+	want = map[string]string{
+		"err.ErrorCause":                             "primary; fallback",
+		"err.ErrorCauses.0.ErrorCause":               "primary",
+		"err.ErrorCauses.1.ErrorCause":               "fallback",
+		"err.ErrorCauses.1.ErrorCauses.0.ErrorCause": "dns",
+		"err.ErrorCauses.1.ErrorCauses.1.ErrorCause": "EOF",
+	}
+	for k, v := range want {
+		if got, ok := h.attrs[k]; !ok || got.String() != v {
+			t.Fatalf("expected %s=%s, got %v", k, v, h.attrs)
+		}
+	}
+	if len(h.attrs) != len(want) {
+		t.Fatalf("unexpected attributes: %v", h.attrs)
+	}
+}
+
+func TestAttrs(t *testing.T) {
+	date := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	e := errors.Constructor(errors.JSONCodec)("query failed").Code(500).
+		AddInfo("table", "users").AddInfo("rows", 3).AddInfo("date", date.Format(time.RFC3339Nano)).
+		Wraps(errors.Constructor(errors.JSONCodec)("timeout").AddInfo("retry", true))
+	e = errors.JSONCodec.Decode([]byte(e.Error()))
+
+	attrs := e.Attrs()
+	var keys []string
+	for _, a := range attrs {
+		keys = append(keys, a.Key)
+	}
+	if got, want := strings.Join(keys, ","), "date,rows,table,ErrorCode,ErrorCause,cause"; got != want {
+		t.Fatalf("expected the keys %s, got %s", want, got)
+	}
+	if attrs[0].Value.Kind() != slog.KindTime || !attrs[0].Value.Time().Equal(date) {
+		t.Fatalf("expected the date to be typed, got %v", attrs[0].Value)
+	}
+	if attrs[1].Value.Kind() != slog.KindInt64 || attrs[1].Value.Int64() != 3 {
+		t.Fatalf("expected the decoded number to be an integer, got %v", attrs[1].Value)
+	}
+	if attrs[2].Value.Kind() != slog.KindString {
+		t.Fatalf("expected a string, got %v", attrs[2].Value)
+	}
+
+	cause := attrs[5].Value
+	if cause.Kind() != slog.KindGroup {
+		t.Fatalf("expected the underlying error to be grouped, got %v", cause)
+	}
+	group := cause.Group()
+	if len(group) != 2 || group[0].Key != "retry" || group[0].Value.Kind() != slog.KindBool || group[1].Value.String() != "timeout" {
+		t.Fatalf("unexpected group: %v", group)
+	}
+
+	newError := errors.Constructor(errors.JSONCodec)
+	attrs = newError("primary").Merge(newError("fallback").WrapsAll(newError("dns").AddInfo("host", "db"), io.EOF)).Attrs()
+	last := attrs[len(attrs)-1]
+	if last.Key != "causes" || last.Value.Kind() != slog.KindGroup {
+		t.Fatalf("expected the branches to be grouped, got %v", attrs)
+	}
+	h := &captureHandler{attrs: make(map[string]slog.Value)}
+	h.add("", last)
+	for k, v := range map[string]string{
+		"causes.0.ErrorCause":          "primary",
+		"causes.1.ErrorCause":          "fallback",
+		"causes.1.causes.0.host":       "db",
+		"causes.1.causes.0.ErrorCause": "dns",
+		"causes.1.causes.1.ErrorCause": "EOF",
+	} {
+		if got, ok := h.attrs[k]; !ok || got.String() != v {
+			t.Fatalf("expected %s=%s, got %v", k, v, h.attrs)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package errors
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+// XMLCodec is an Error Encoder/Decoder object using XML as the serialization
+// format.
+// Since encoding/xml cannot marshal maps, the information entries are encoded
+// as a list of elements sorted by key. Their values are encoded as text and are
+// therefore decoded as strings.
+// A wrapped error that is not of type Error is encoded as an Error holding its
+// message.
+var XMLCodec Codec
+
+func init() {
+	XMLCodec = NewCodec(toXML, fromXML)
+}
+
+// xmlDocument is the root element of an XML encoded Error.
+type xmlDocument struct {
+	XMLName xml.Name `xml:"Error"`
+	xmlError
+}
+
+type xmlError struct {
+	Info     []xmlInfo   `xml:"ErrorInfo>Info,omitempty"`
+	Code     string      `xml:"ErrorCode,omitempty"`
+	Cause    string      `xml:"ErrorCause"`
+	Severity Severity    `xml:"ErrorSeverity,omitempty"`
+	Stack    []Frame     `xml:"ErrorStack>Frame,omitempty"`
+	Source   *xmlError   `xml:"ErrorSource,omitempty"`
+	Causes   []*xmlError `xml:"ErrorCauses>Error,omitempty"`
+}
+
+type xmlInfo struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// newXMLError converts e and the errors it wraps. As with Clone, an Error
+// reachable through several branches is converted once and a cycle is left
+// out.
+func newXMLError(e *Error, converted map[*Error]*xmlError) *xmlError {
+	if e == nil {
+		return nil
+	}
+	if x, ok := converted[e]; ok {
+		return x
+	}
+	converted[e] = nil
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	x := &xmlError{
+		Code:     e.ErrorCode,
+		Cause:    e.ErrorCause,
+		Severity: e.ErrorSeverity,
+		Stack:    e.ErrorStack,
+		Source:   newXMLError(e.Underlying, converted),
+	}
+	if e.Underlying == nil && e.underlyingStd != nil {
+		x.Source = &xmlError{Cause: e.underlyingStd.Error()}
+	}
+	for _, m := range e.allCauses() {
+		if xm := newXMLError(m, converted); xm != nil {
+			x.Causes = append(x.Causes, xm)
+		}
+	}
+	keys := make([]string, 0, len(e.ErrorInfo))
+	for k := range e.ErrorInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		x.Info = append(x.Info, xmlInfo{k, fmt.Sprint(e.ErrorInfo[k])})
+	}
+	converted[e] = x
+	return x
+}
+
+func (x *xmlError) toError() *Error {
+	if x == nil {
+		return nil
+	}
+	e := &Error{
+		ErrorCode:     x.Code,
+		ErrorCause:    x.Cause,
+		ErrorSeverity: x.Severity,
+		ErrorStack:    x.Stack,
+		Underlying:    x.Source.toError(),
+		codec:         XMLCodec,
+	}
+	for _, info := range x.Info {
+		e.AddInfo(info.Key, info.Value)
+	}
+	for _, m := range x.Causes {
+		e.ErrorCauses = append(e.ErrorCauses, m.toError())
+	}
+	return e
+}
+
+// toXML will enable the encoding of an Error as an XML document.
+func toXML(i interface{}) ([]byte, error) {
+	if e, ok := i.(*Error); ok && e != nil {
+		i = xmlDocument{xmlError: *newXMLError(e, make(map[*Error]*xmlError))}
+	}
+	return xml.MarshalIndent(i, "", " ")
+}
+
+// fromXML enables the decoding of an XML document into an Error object.
+func fromXML(b []byte) *Error {
+	var x xmlError
+	err := xml.Unmarshal(b, &x)
+	if err != nil {
+		return &Error{ErrorCause: string(b), codec: XMLCodec}
+	}
+	return x.toError()
+}
@@ -0,0 +1,43 @@
+package errors_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+)
+
+func TestXMLCodec(t *testing.T) {
+	newXMLError := errors.Constructor(errors.XMLCodec)
+	e := newXMLError("query failed").Code(500).Severity(errors.SeverityWarn).
+		AddInfo("table", "users").AddInfo("attempt", 3)
+	e = newXMLError("loading profile").AddInfo("user", "42").Wraps(e)
+
+	s := e.Error()
+	if !strings.HasPrefix(s, "<Error>") {
+		t.Fatalf("expected an XML document, got %s", s)
+	}
+	if strings.Index(s, `key="attempt"`) > strings.Index(s, `key="table"`) {
+		t.Fatalf("expected the info entries to be sorted by key, got %s", s)
+	}
+
+	d := errors.XMLCodec.Decode([]byte(s))
+	if d.ErrorCause != "loading profile" || d.ErrorInfo["user"] != "42" {
+		t.Fatalf("unexpected decoded error: %#v", d)
+	}
+	u := d.Underlying
+	if u == nil || u.ErrorCause != "query failed" || !u.HasCode(500) || u.GetSeverity() != errors.SeverityWarn {
+		t.Fatalf("unexpected decoded underlying error: %#v", u)
+	}
+	if u.ErrorInfo["table"] != "users" || u.ErrorInfo["attempt"] != "3" {
+		t.Fatalf("unexpected decoded underlying info: %v", u.ErrorInfo)
+	}
+	if d.Error() != s {
+		t.Fatal("expected the decoded error to encode identically")
+	}
+
+	bad := errors.XMLCodec.Decode([]byte("not xml"))
+	if bad.ErrorCause != "not xml" {
+		t.Fatalf("expected invalid documents to be kept as the cause, got %q", bad.ErrorCause)
+	}
+}
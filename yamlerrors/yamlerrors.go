@@ -0,0 +1,102 @@
+// Package yamlerrors provides a codec serializing the Error type of the errors
+// package as YAML, which suits human-edited fixtures of expected errors.
+// It lives in its own package so that the errors package does not depend on a
+// YAML library.
+package yamlerrors
+
+import (
+	"github.com/atdiar/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLCodec is an Error Encoder/Decoder object using YAML as the serialization
+// format. The fields are named as in the JSON serialization.
+// Unlike with the JSONCodec, integers stored as information are decoded as
+// integers rather than floating point numbers. A wrapped error that is not of
+// type Error is encoded as an Error holding its message. The causes merged
+// with Merge or wrapped by WrapsAll are encoded under the ErrorCauses field.
+var YAMLCodec errors.Codec
+
+func init() {
+	YAMLCodec = errors.NewCodec(toYAML, fromYAML)
+}
+
+type yamlError struct {
+	Info     map[string]interface{} `yaml:"ErrorInfo,omitempty"`
+	Code     string                 `yaml:"ErrorCode,omitempty"`
+	Cause    string                 `yaml:"ErrorCause"`
+	Severity string                 `yaml:"ErrorSeverity,omitempty"`
+	Stack    []errors.Frame         `yaml:"ErrorStack,omitempty"`
+	Source   *yamlError             `yaml:"ErrorSource,omitempty"`
+	Causes   []*yamlError           `yaml:"ErrorCauses,omitempty"`
+}
+
+func newYAMLError(e *errors.Error) *yamlError {
+	if e == nil {
+		return nil
+	}
+	y := &yamlError{
+		Info:   e.ErrorInfo,
+		Code:   e.ErrorCode,
+		Cause:  e.ErrorCause,
+		Stack:  e.ErrorStack,
+		Source: newYAMLError(e.Underlying),
+	}
+	if e.ErrorSeverity != 0 {
+		y.Severity = e.ErrorSeverity.String()
+	}
+	if e.Underlying == nil {
+		if std := e.Source(); std != nil {
+			y.Source = &yamlError{Cause: std.Error()}
+		}
+	}
+	for _, b := range e.Branches() {
+		if be := errors.As(b); be != nil {
+			y.Causes = append(y.Causes, newYAMLError(be))
+			continue
+		}
+		y.Causes = append(y.Causes, &yamlError{Cause: b.Error()})
+	}
+	return y
+}
+
+func (y *yamlError) toError() *errors.Error {
+	if y == nil {
+		return nil
+	}
+	e := errors.Decoded(YAMLCodec, y.Cause).CodeString(y.Code)
+	e.ErrorStack = y.Stack
+	e.Underlying = y.Source.toError()
+	if y.Severity != "" {
+		var s errors.Severity
+		if err := s.UnmarshalText([]byte(y.Severity)); err == nil {
+			e.Severity(s)
+		}
+	}
+	for k, v := range y.Info {
+		e.AddInfo(k, v)
+	}
+	for _, c := range y.Causes {
+		e.ErrorCauses = append(e.ErrorCauses, c.toError())
+	}
+	return e
+}
+
+// toYAML encodes an Error as a YAML document. The chain is cloned beforehand,
+// so that a cyclic chain is encoded up to the first repeated Error.
+func toYAML(i interface{}) ([]byte, error) {
+	if e, ok := i.(*errors.Error); ok && e != nil {
+		i = newYAMLError(e.Clone())
+	}
+	return yaml.Marshal(i)
+}
+
+// fromYAML decodes a YAML document into an Error. If the document is
+// malformed, the returned Error holds the failure as its cause.
+func fromYAML(b []byte) *errors.Error {
+	var y yamlError
+	if err := yaml.Unmarshal(b, &y); err != nil {
+		return errors.Decoded(YAMLCodec, "yamlerrors: unable to decode: "+err.Error())
+	}
+	return y.toError()
+}
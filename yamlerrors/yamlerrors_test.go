@@ -0,0 +1,65 @@
+package yamlerrors_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/atdiar/errors"
+	"github.com/atdiar/errors/yamlerrors"
+)
+
+func TestFixture(t *testing.T) {
+	b, err := os.ReadFile("testdata/not_found.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := yamlerrors.YAMLCodec.Decode(b)
+	if e.ErrorCause != "profile not found" || !e.HasCode(404) || e.GetSeverity() != errors.SeverityWarn {
+		t.Fatalf("unexpected error: %#v", e)
+	}
+	if e.ErrorInfo["attempts"] != 3 || e.ErrorInfo["retryable"] != false || e.ErrorInfo["user"] != "alice" {
+		t.Fatalf("expected the information to keep its types, got %#v", e.ErrorInfo)
+	}
+	if e.Underlying == nil || e.Underlying.ErrorCause != "no rows in result set" || e.Underlying.ErrorInfo["table"] != "users" {
+		t.Fatalf("unexpected underlying error: %#v", e.Underlying)
+	}
+
+	expected := errors.Constructor(errors.JSONCodec)("profile not found").Code(404).
+		AddInfo("attempts", 3).AddInfo("retryable", false).AddInfo("user", "alice").
+		Wraps(errors.Constructor(errors.JSONCodec)("no rows in result set").AddInfo("table", "users"))
+	if !errors.Equal(e, expected) {
+		t.Fatalf("expected the fixture to match %v", expected)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	newError := errors.Constructor(yamlerrors.YAMLCodec)
+	e := newError("query failed").Code(500).Severity(errors.SeverityFatal).
+		AddInfo("rows", 12).AddInfo("ratio", 0.5).AddInfo("tags", []interface{}{"a", "b"}).
+		Wraps(newError("timeout").CodeString("E_TIMEOUT"))
+
+	s := e.Error()
+	if !strings.Contains(s, "ErrorCause: query failed") {
+		t.Fatalf("expected a YAML document, got %s", s)
+	}
+	d := yamlerrors.YAMLCodec.Decode([]byte(s))
+	if !errors.Equal(d, e) || d.GetSeverity() != errors.SeverityFatal {
+		t.Fatalf("expected %v, got %v", e, d)
+	}
+	if d.Error() != s {
+		t.Fatalf("expected the decoded error to use the YAML codec, got %s", d.Error())
+	}
+
+	m := e.Merge(newError("fallback failed").Code(504))
+	if d := yamlerrors.YAMLCodec.Decode([]byte(m.Error())); !errors.Equal(d, m) {
+		t.Fatalf("expected the merged causes to survive the round trip, got %v", d)
+	}
+
+	if d := yamlerrors.YAMLCodec.Decode([]byte("ErrorCause: [unterminated")); !strings.HasPrefix(d.ErrorCause, "yamlerrors: unable to decode") {
+		t.Fatalf("expected a malformed document to be reported, got %q", d.ErrorCause)
+	}
+	if d := yamlerrors.YAMLCodec.Decode([]byte("ErrorInfo: [1, 2]")); !strings.HasPrefix(d.ErrorCause, "yamlerrors: unable to decode") {
+		t.Fatalf("expected a mistyped field to be reported, got %q", d.ErrorCause)
+	}
+}